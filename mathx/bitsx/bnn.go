@@ -0,0 +1,330 @@
+package bitsx
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math"
+)
+
+// 2値化ニューラルネットワーク(BNN)の推論。
+// 活性は (バッチ x 入力数) の行列で、ビットの1を+1、0を-1とみなす。
+
+// BinaryDenseは全結合層。重みは2値(Matrix)か3値(TernaryMatrix)で、形状は (出力数 x 入力数)。
+// 出力oのビットは、入力と重みのo行目の内積をzとして z + bias[o] >= thresholds[o] なら1になる。
+//
+// バッチ正規化 gamma*(z-mu)/sigma + beta >= 0 は、gamma > 0 なら z >= mu - beta*sigma/gamma と畳み込める。
+// gamma < 0 の場合は不等号の向きが逆になるので、重みのo行目を反転(zの符号を反転)してから畳み込む。
+// これらはNewBinaryDenseFromBatchNormとNewTernaryDenseFromBatchNormが行う。
+type BinaryDense struct {
+	weights    *Matrix
+	ternary    *TernaryMatrix
+	thresholds []int
+	bias       []int
+}
+
+// NewBinaryDenseは2値の重みの層を作る。biasはnilでもよい。引数は複製しない。
+func NewBinaryDense(weights *Matrix, thresholds, bias []int) (*BinaryDense, error) {
+	if err := weights.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	layer := &BinaryDense{weights: weights, thresholds: thresholds, bias: bias}
+	if err := layer.validate(); err != nil {
+		return nil, err
+	}
+	return layer, nil
+}
+
+// NewTernaryDenseは3値の重みの層を作る。biasはnilでもよい。引数は複製しない。
+func NewTernaryDense(weights *TernaryMatrix, thresholds, bias []int) (*BinaryDense, error) {
+	layer := &BinaryDense{ternary: weights, thresholds: thresholds, bias: bias}
+	if err := layer.validate(); err != nil {
+		return nil, err
+	}
+	return layer, nil
+}
+
+// NewBinaryDenseFromBatchNormは、2値の重みと直後のバッチ正規化を畳み込んだ層を作る。
+// gamma < 0 の出力は重みの行を反転するので、weightsは複製してから使う。
+func NewBinaryDenseFromBatchNorm(weights *Matrix, gamma, beta, mu, sigma []float32) (*BinaryDense, error) {
+	if err := weights.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	thresholds, flips, err := foldBatchNorm(weights.rows, weights.cols, gamma, beta, mu, sigma)
+	if err != nil {
+		return nil, err
+	}
+
+	folded := weights.Clone()
+	stride := folded.Stride()
+	for o, flip := range flips {
+		if !flip {
+			continue
+		}
+		row := folded.data[o*stride : (o+1)*stride]
+		for i := range row {
+			row[i] = ^row[i]
+		}
+	}
+	// 反転により端数ビットが1になる為、0に戻す
+	folded.ApplyTailMask()
+	folded.invalidateRanks()
+	return NewBinaryDense(folded, thresholds, nil)
+}
+
+// NewTernaryDenseFromBatchNormは、3値の重みと直後のバッチ正規化を畳み込んだ層を作る。
+// gamma < 0 の出力は重みの行の符号を反転するので、weightsは複製してから使う。
+func NewTernaryDenseFromBatchNorm(weights *TernaryMatrix, gamma, beta, mu, sigma []float32) (*BinaryDense, error) {
+	thresholds, flips, err := foldBatchNorm(weights.Rows(), weights.Cols(), gamma, beta, mu, sigma)
+	if err != nil {
+		return nil, err
+	}
+
+	folded := weights.Clone()
+	stride := folded.sign.Stride()
+	for o, flip := range flips {
+		if !flip {
+			continue
+		}
+		// 0でない位置だけ+1と-1を入れ替える
+		sign := folded.sign.data[o*stride : (o+1)*stride]
+		nonZero := folded.nonZero.data[o*stride : (o+1)*stride]
+		for i := range sign {
+			sign[i] ^= nonZero[i]
+		}
+	}
+	folded.sign.invalidateRanks()
+	return NewTernaryDense(folded, thresholds, nil)
+}
+
+// foldBatchNormは、出力ごとの閾値と、重みの行を反転すべきかを返す。
+// zは [-in, in] の整数なので、閾値は [-in, in+1] に収める。
+func foldBatchNorm(out, in int, gamma, beta, mu, sigma []float32) ([]int, []bool, error) {
+	for _, p := range []struct {
+		name string
+		xs   []float32
+	}{{"gamma", gamma}, {"beta", beta}, {"mu", mu}, {"sigma", sigma}} {
+		if len(p.xs) != out {
+			return nil, nil, fmt.Errorf("len(%s) = 出力数 であるべき: len(%s) = %d, 出力数 = %d", p.name, p.name, len(p.xs), out)
+		}
+	}
+
+	thresholds := make([]int, out)
+	flips := make([]bool, out)
+	for o := range out {
+		if !(sigma[o] > 0) {
+			return nil, nil, fmt.Errorf("sigma[%d] > 0 であるべき: sigma[%d] = %v", o, o, sigma[o])
+		}
+
+		g := float64(gamma[o])
+		if g == 0 {
+			// 出力はzによらずbetaの符号で決まる
+			if beta[o] >= 0 {
+				thresholds[o] = -in
+			} else {
+				thresholds[o] = in + 1
+			}
+			continue
+		}
+
+		t := float64(mu[o]) - float64(beta[o])*float64(sigma[o])/g
+		if g < 0 {
+			// z <= t を、反転した行の内積 -z について -z >= -t とする
+			t = -t
+			flips[o] = true
+		}
+		if math.IsNaN(t) {
+			return nil, nil, fmt.Errorf("%d番目の閾値がNaNになった: gamma = %v, beta = %v, mu = %v, sigma = %v", o, gamma[o], beta[o], mu[o], sigma[o])
+		}
+
+		switch t = math.Ceil(t); {
+		case t < float64(-in):
+			thresholds[o] = -in
+		case t > float64(in+1):
+			thresholds[o] = in + 1
+		default:
+			thresholds[o] = int(t)
+		}
+	}
+	return thresholds, flips, nil
+}
+
+func (l *BinaryDense) validate() error {
+	if (l.weights == nil) == (l.ternary == nil) {
+		return fmt.Errorf("2値と3値の重みのどちらか一方だけを持つべき")
+	}
+
+	out := l.OutputSize()
+	if len(l.thresholds) != out {
+		return fmt.Errorf("len(thresholds) = 出力数 であるべき: len(thresholds) = %d, 出力数 = %d", len(l.thresholds), out)
+	}
+
+	if l.bias != nil && len(l.bias) != out {
+		return fmt.Errorf("len(bias) = 出力数 であるべき: len(bias) = %d, 出力数 = %d", len(l.bias), out)
+	}
+	return nil
+}
+
+func (l *BinaryDense) InputSize() int {
+	if l.ternary != nil {
+		return l.ternary.Cols()
+	}
+	return l.weights.cols
+}
+
+func (l *BinaryDense) OutputSize() int {
+	if l.ternary != nil {
+		return l.ternary.Rows()
+	}
+	return l.weights.rows
+}
+
+// Forwardは、xの各行を入力とした出力のビットを (x.Rows() x 出力数) の行列で返す。
+func (l *BinaryDense) Forward(x *Matrix) (*Matrix, error) {
+	in := l.InputSize()
+	if x.cols != in {
+		return nil, fmt.Errorf("x.Cols = 入力数 であるべき: x.Cols = %d, 入力数 = %d", x.cols, in)
+	}
+
+	var z []int
+	var err error
+	if l.ternary != nil {
+		z, err = l.ternary.Dot(x)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Dotは一致するビットの数aを返すので、±1の内積は a - (in - a)
+		z, err = x.Dot(l.weights)
+		if err != nil {
+			return nil, err
+		}
+		for i, a := range z {
+			z[i] = 2*a - in
+		}
+	}
+
+	out := l.OutputSize()
+	y, err := NewZerosMatrix(x.rows, out)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := y.Stride()
+	for r := range x.rows {
+		row := y.data[r*stride : (r+1)*stride]
+		for o, v := range z[r*out : (r+1)*out] {
+			if l.bias != nil {
+				v += l.bias[o]
+			}
+			if v >= l.thresholds[o] {
+				row[o/64] |= 1 << uint(o%64)
+			}
+		}
+	}
+	return y, nil
+}
+
+type gobEncodedBinaryDense struct {
+	Weights    *Matrix
+	Ternary    *TernaryMatrix
+	Thresholds []int
+	Bias       []int
+}
+
+func (l *BinaryDense) GobEncode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	payload := gobEncodedBinaryDense{Weights: l.weights, Ternary: l.ternary, Thresholds: l.thresholds, Bias: l.bias}
+	if err := gob.NewEncoder(buf).Encode(payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (l *BinaryDense) GobDecode(b []byte) error {
+	var payload gobEncodedBinaryDense
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&payload); err != nil {
+		return err
+	}
+
+	decoded := &BinaryDense{weights: payload.Weights, ternary: payload.Ternary, thresholds: payload.Thresholds, bias: payload.Bias}
+	if err := decoded.validate(); err != nil {
+		return fmt.Errorf("デコードされたBinaryDenseが不正: %w", err)
+	}
+
+	*l = *decoded
+	return nil
+}
+
+// Sequentialは、BinaryDenseを順に適用するモデル。gobx.Saveで丸ごと保存できる。
+type Sequential struct {
+	layers []*BinaryDense
+}
+
+// NewSequentialは、各層の出力数が次の層の入力数と等しいかを検査してモデルを作る。
+func NewSequential(layers ...*BinaryDense) (*Sequential, error) {
+	if err := validateSequentialLayers(layers); err != nil {
+		return nil, err
+	}
+	return &Sequential{layers: layers}, nil
+}
+
+func validateSequentialLayers(layers []*BinaryDense) error {
+	if len(layers) == 0 {
+		return fmt.Errorf("len(layers) > 0 であるべき")
+	}
+
+	for i := 1; i < len(layers); i++ {
+		if out, in := layers[i-1].OutputSize(), layers[i].InputSize(); out != in {
+			return fmt.Errorf("%d層目の出力数と%d層目の入力数が不一致: %d vs %d", i-1, i, out, in)
+		}
+	}
+	return nil
+}
+
+func (s *Sequential) Len() int {
+	return len(s.layers)
+}
+
+func (s *Sequential) Layer(i int) *BinaryDense {
+	return s.layers[i]
+}
+
+func (s *Sequential) Forward(x *Matrix) (*Matrix, error) {
+	for i, layer := range s.layers {
+		y, err := layer.Forward(x)
+		if err != nil {
+			return nil, fmt.Errorf("%d層目: %w", i, err)
+		}
+		x = y
+	}
+	return x, nil
+}
+
+type gobEncodedSequential struct {
+	Layers []*BinaryDense
+}
+
+func (s *Sequential) GobEncode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(gobEncodedSequential{Layers: s.layers}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Sequential) GobDecode(b []byte) error {
+	var payload gobEncodedSequential
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&payload); err != nil {
+		return err
+	}
+
+	if err := validateSequentialLayers(payload.Layers); err != nil {
+		return fmt.Errorf("デコードされたSequentialが不正: %w", err)
+	}
+
+	s.layers = payload.Layers
+	return nil
+}
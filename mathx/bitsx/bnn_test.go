@@ -0,0 +1,325 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+
+	"github.com/sw965/omw/encoding/gobx"
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+// 各出力のビットを、±1の内積から素朴に求める。
+func naiveDenseForward(t *testing.T, x *bitsx.Matrix, weight func(o, i int) int, out int, thresholds, bias []int) *bitsx.Matrix {
+	t.Helper()
+	y, err := bitsx.NewZerosMatrix(x.Rows(), out)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	for r := range x.Rows() {
+		for o := range out {
+			z := 0
+			for i := range x.Cols() {
+				z += (2*int(mustBit(t, x, r, i)) - 1) * weight(o, i)
+			}
+			if bias != nil {
+				z += bias[o]
+			}
+			if z >= thresholds[o] {
+				if err := y.Set(r, o); err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+			}
+		}
+	}
+	return y
+}
+
+// 各出力のビットを、バッチ正規化 gamma*(z-mu)/sigma + beta >= 0 から素朴に求める。
+func naiveBatchNormForward(t *testing.T, x *bitsx.Matrix, weight func(o, i int) int, gamma, beta, mu, sigma []float32) *bitsx.Matrix {
+	t.Helper()
+	out := len(gamma)
+	y, err := bitsx.NewZerosMatrix(x.Rows(), out)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	for r := range x.Rows() {
+		for o := range out {
+			z := 0
+			for i := range x.Cols() {
+				z += (2*int(mustBit(t, x, r, i)) - 1) * weight(o, i)
+			}
+			v := float64(gamma[o])*(float64(z)-float64(mu[o]))/float64(sigma[o]) + float64(beta[o])
+			if v >= 0 {
+				if err := y.Set(r, o); err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+			}
+		}
+	}
+	return y
+}
+
+// 境界でのずれを避ける為、muは整数にならないようにする。
+// gammaは負と0も含める。
+func newRandBatchNorm(rng *rand.Rand, n, limit int) (gamma, beta, mu, sigma []float32) {
+	gamma = make([]float32, n)
+	beta = make([]float32, n)
+	mu = make([]float32, n)
+	sigma = make([]float32, n)
+	for o := range n {
+		switch o % 3 {
+		case 0:
+			gamma[o] = 0.5 + rng.Float32()
+		case 1:
+			gamma[o] = -0.5 - rng.Float32()
+		}
+		beta[o] = 2*rng.Float32() - 1
+		mu[o] = float32(rng.IntN(2*limit+1)-limit) + 0.25
+		sigma[o] = 0.5 + rng.Float32()
+	}
+	return gamma, beta, mu, sigma
+}
+
+func newRandThresholds(rng *rand.Rand, n, limit int) []int {
+	xs := make([]int, n)
+	for i := range xs {
+		xs[i] = rng.IntN(2*limit+1) - limit
+	}
+	return xs
+}
+
+func TestBinaryDenseForward(t *testing.T) {
+	rng := rand.New(rand.NewPCG(63, 64))
+	in, out := 100, 70
+	x, err := bitsx.NewRandMatrix(5, in, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	thresholds := newRandThresholds(rng, out, 10)
+	bias := newRandThresholds(rng, out, 5)
+
+	t.Run("正常_2値の重み", func(t *testing.T) {
+		w, err := bitsx.NewRandMatrix(out, in, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		for _, b := range [][]int{nil, bias} {
+			layer, err := bitsx.NewBinaryDense(w, thresholds, b)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			got, err := layer.Forward(x)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			want := naiveDenseForward(t, x, func(o, i int) int { return 2*int(mustBit(t, w, o, i)) - 1 }, out, thresholds, b)
+			if !got.Equal(want) {
+				t.Fatalf("値の不一致: bias = %v", b)
+			}
+		}
+	})
+
+	t.Run("正常_3値の重み", func(t *testing.T) {
+		values := newRandTernaryValues(rng, out*in)
+		w, err := bitsx.NewTernaryMatrix(out, in, values)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		layer, err := bitsx.NewTernaryDense(w, thresholds, bias)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err := layer.Forward(x)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want := naiveDenseForward(t, x, func(o, i int) int { return int(values[o*in+i]) }, out, thresholds, bias)
+		if !got.Equal(want) {
+			t.Fatalf("値の不一致")
+		}
+	})
+
+	t.Run("正常_バッチ正規化の畳み込み", func(t *testing.T) {
+		gamma, beta, mu, sigma := newRandBatchNorm(rng, out, 10)
+		// 閾値が [-in, in] の外になる場合
+		mu[3], mu[4] = float32(in)+5.5, -float32(in)-5.5
+
+		w, err := bitsx.NewRandMatrix(out, in, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		original := w.Clone()
+		layer, err := bitsx.NewBinaryDenseFromBatchNorm(w, gamma, beta, mu, sigma)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err := layer.Forward(x)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want := naiveBatchNormForward(t, x, func(o, i int) int { return 2*int(mustBit(t, w, o, i)) - 1 }, gamma, beta, mu, sigma)
+		if !got.Equal(want) {
+			t.Fatalf("2値の重みで値の不一致")
+		}
+		if !w.Equal(original) {
+			t.Fatalf("引数の重みが書き換えられた")
+		}
+
+		values := newRandTernaryValues(rng, out*in)
+		tw, err := bitsx.NewTernaryMatrix(out, in, values)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		tOriginal := tw.Clone()
+		layer, err = bitsx.NewTernaryDenseFromBatchNorm(tw, gamma, beta, mu, sigma)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err = layer.Forward(x)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want = naiveBatchNormForward(t, x, func(o, i int) int { return int(values[o*in+i]) }, gamma, beta, mu, sigma)
+		if !got.Equal(want) {
+			t.Fatalf("3値の重みで値の不一致")
+		}
+		if !tw.Equal(tOriginal) {
+			t.Fatalf("引数の重みが書き換えられた")
+		}
+	})
+
+	t.Run("異常_バッチ正規化の引数が不正", func(t *testing.T) {
+		w, err := bitsx.NewZerosMatrix(out, in)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		gamma, beta, mu, sigma := newRandBatchNorm(rng, out, 10)
+		if _, err := bitsx.NewBinaryDenseFromBatchNorm(w, gamma[:out-1], beta, mu, sigma); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+
+		sigma[0] = 0
+		if _, err := bitsx.NewBinaryDenseFromBatchNorm(w, gamma, beta, mu, sigma); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		tw, err := bitsx.NewZerosTernaryMatrix(out, in)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := bitsx.NewTernaryDenseFromBatchNorm(tw, gamma, beta, mu, sigma); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+
+	t.Run("異常_引数が不正", func(t *testing.T) {
+		w, err := bitsx.NewZerosMatrix(out, in)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := bitsx.NewBinaryDense(w, thresholds[:out-1], nil); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := bitsx.NewBinaryDense(w, thresholds, bias[:1]); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+
+		layer, err := bitsx.NewBinaryDense(w, thresholds, nil)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		wrong, err := bitsx.NewZerosMatrix(1, in+1)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := layer.Forward(wrong); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestSequential(t *testing.T) {
+	rng := rand.New(rand.NewPCG(65, 66))
+	sizes := []int{130, 64, 40, 10}
+
+	layers := make([]*bitsx.BinaryDense, len(sizes)-1)
+	for i := range layers {
+		in, out := sizes[i], sizes[i+1]
+		var layer *bitsx.BinaryDense
+		var err error
+		if i%2 == 0 {
+			var w *bitsx.Matrix
+			w, err = bitsx.NewRandMatrix(out, in, 0, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			layer, err = bitsx.NewBinaryDense(w, newRandThresholds(rng, out, 4), nil)
+		} else {
+			var w *bitsx.TernaryMatrix
+			w, err = bitsx.NewTernaryMatrix(out, in, newRandTernaryValues(rng, out*in))
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			layer, err = bitsx.NewTernaryDense(w, newRandThresholds(rng, out, 4), newRandThresholds(rng, out, 2))
+		}
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		layers[i] = layer
+	}
+
+	model, err := bitsx.NewSequential(layers...)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	x, err := bitsx.NewRandMatrix(8, sizes[0], 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	want := x
+	for _, layer := range layers {
+		want, err = layer.Forward(want)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+	}
+
+	got, err := model.Forward(x)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Fatalf("各層を順に適用した結果と一致しない")
+	}
+
+	t.Run("正常_gobで1つのファイルから読み込める", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "model.gob")
+		if err := gobx.Save(model, path); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		loaded, err := gobx.Load[*bitsx.Sequential](path)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if loaded.Len() != model.Len() {
+			t.Fatalf("層の数の不一致: got = %d, want = %d", loaded.Len(), model.Len())
+		}
+		got, err := loaded.Forward(x)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("読み込んだモデルの出力が一致しない")
+		}
+	})
+
+	t.Run("異常_層の形状の不一致", func(t *testing.T) {
+		if _, err := bitsx.NewSequential(layers[0], layers[2]); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := bitsx.NewSequential(); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package bitsx
+
+import "iter"
+
+// etfStateは、NewETFMatricesの探索中に、全ての組のハミング距離を保つ。
+// 1ビットの反転は、反転した行列と他のn-1個との距離を±1ずつ変えるだけなので、
+// ハミング距離を数え直さずにO(n)で更新できる。コストはETFCostと同じく、全ての組の距離から求める。
+type etfState struct {
+	ms     Matrices
+	stride int
+
+	// distances[i*n+j] は ms[i] と ms[j] の距離 (対称)
+	distances []int
+}
+
+func newETFState(ms Matrices) (*etfState, error) {
+	n := len(ms)
+	s := &etfState{
+		ms:        ms,
+		stride:    ms[0].Stride(),
+		distances: make([]int, n*n),
+	}
+
+	for i := range n {
+		for j := i + 1; j < n; j++ {
+			d, err := ms[i].HammingDistance(ms[j])
+			if err != nil {
+				return nil, err
+			}
+			s.distances[i*n+j] = d
+			s.distances[j*n+i] = d
+		}
+	}
+	return s, nil
+}
+
+// pairDistancesは、(i, j) (i < j) の組の距離を辞書順に返す。
+// deltasがnilでなければ、ms[index]と他の行列との距離にdeltas[j]を足す。
+func (s *etfState) pairDistances(index int, deltas []int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		n := len(s.ms)
+		for i := range n {
+			for j := i + 1; j < n; j++ {
+				d := s.distances[i*n+j]
+				if deltas != nil {
+					if i == index {
+						d += deltas[j]
+					} else if j == index {
+						d += deltas[i]
+					}
+				}
+				if !yield(d) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *etfState) cost() float32 {
+	return etfCost(s.pairDistances(-1, nil))
+}
+
+// flipを反転した後の、他の行列との距離の変化量を、jの順にfへ渡す。状態は変更しない。
+func (s *etfState) forEachDelta(flip Flip, f func(j, delta int)) {
+	idx := flip.Row*s.stride + flip.Col/64
+	shift := uint(flip.Col % 64)
+	bit := (s.ms[flip.Index].data[idx] >> shift) & 1
+
+	for j, m := range s.ms {
+		if j == flip.Index {
+			continue
+		}
+		// 反転前に一致していれば距離が1増え、異なっていれば1減る
+		if (m.data[idx]>>shift)&1 == bit {
+			f(j, 1)
+		} else {
+			f(j, -1)
+		}
+	}
+}
+
+// flipを反転した場合のコストを返す。状態は変更しない為、複数のgoroutineから同時に呼べる。
+func (s *etfState) flipCost(flip Flip) float32 {
+	deltas := make([]int, len(s.ms))
+	s.forEachDelta(flip, func(j, delta int) {
+		deltas[j] = delta
+	})
+	return etfCost(s.pairDistances(flip.Index, deltas))
+}
+
+func (s *etfState) apply(flip Flip) {
+	n := len(s.ms)
+	i := flip.Index
+	s.forEachDelta(flip, func(j, delta int) {
+		d := s.distances[i*n+j]
+		s.distances[i*n+j] = d + delta
+		s.distances[j*n+i] = d + delta
+	})
+	s.ms[i].data[flip.Row*s.stride+flip.Col/64] ^= 1 << uint(flip.Col%64)
+	s.ms[i].invalidateRanks()
+}
@@ -0,0 +1,277 @@
+package bitsx
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+const (
+	// 部分ビット列(チャンク)の最大幅。各チャンクをキーとするハッシュ表を持つ
+	hammingIndexChunkBits = 16
+
+	// 登録数がこれ未満なら、ハッシュ表を引かずに全件を比較する
+	hammingIndexBruteForceRows = 1024
+)
+
+// Neighborは、HammingIndexの検索結果の1件。
+type Neighbor struct {
+	// 登録順の行番号
+	Index    int
+	Distance int
+}
+
+// HammingIndexは、行列の各行を符号として登録し、ハミング距離が近い行を厳密に検索する。
+//
+// Multi-Index Hashingを用いる。各行をnumChunks個のチャンクに分け、チャンクごとにハッシュ表を持つ。
+// 鳩の巣原理より、距離がr以下の行は、少なくとも1つのチャンクで距離 floor(r/numChunks) 以下になる為、
+// 各チャンクの近傍のキーを引くだけで、候補を漏れなく集められる。
+// 引くキーの数が登録数を上回る場合や、登録数が少ない場合は、全件の比較(Dotのカーネル)に切り替える。
+type HammingIndex struct {
+	cols   int
+	stride int
+	rows   int
+	data   []uint64
+
+	chunkStarts []int
+	chunkWidths []int
+	tables      []map[uint64][]int
+}
+
+func NewHammingIndex(m *Matrix) (*HammingIndex, error) {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	numChunks := (m.cols + hammingIndexChunkBits - 1) / hammingIndexChunkBits
+	idx := &HammingIndex{
+		cols:        m.cols,
+		stride:      m.Stride(),
+		chunkStarts: make([]int, numChunks),
+		chunkWidths: make([]int, numChunks),
+		tables:      make([]map[uint64][]int, numChunks),
+	}
+
+	// 列をできるだけ均等な幅のチャンクに分ける
+	q := m.cols / numChunks
+	r := m.cols % numChunks
+	start := 0
+	for i := range numChunks {
+		width := q
+		if i < r {
+			width++
+		}
+		idx.chunkStarts[i] = start
+		idx.chunkWidths[i] = width
+		idx.tables[i] = map[uint64][]int{}
+		start += width
+	}
+
+	if err := idx.Add(m); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Lenは登録されている行数を返す。
+func (idx *HammingIndex) Len() int {
+	return idx.rows
+}
+
+// Addはmの各行を、登録順の続きの行番号で追加する。
+func (idx *HammingIndex) Add(m *Matrix) error {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return err
+	}
+
+	if m.cols != idx.cols {
+		return fmt.Errorf("列数が不一致: index.Cols = %d, m.Cols = %d", idx.cols, m.cols)
+	}
+
+	idx.data = append(idx.data, m.data...)
+	for r := range m.rows {
+		row := m.data[r*idx.stride : (r+1)*idx.stride]
+		for i, table := range idx.tables {
+			key := idx.chunkKey(row, i)
+			table[key] = append(table[key], idx.rows)
+		}
+		idx.rows++
+	}
+	return nil
+}
+
+// KNNは、queryとの距離が近い順にk件を返す。距離が等しい場合は、行番号が小さい順。
+// k > Len() の場合はLen()件を返す。queryは (1 x Cols) であるべき。
+func (idx *HammingIndex) KNN(query *Matrix, k int) ([]Neighbor, error) {
+	if err := idx.validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	if k <= 0 {
+		return nil, fmt.Errorf("k > 0 であるべき: k = %d", k)
+	}
+
+	k = min(k, idx.rows)
+	if idx.rows < hammingIndexBruteForceRows {
+		return idx.bruteForceKNN(query, k)
+	}
+
+	numChunks := len(idx.tables)
+	visited := make([]bool, idx.rows)
+	var candidates []Neighbor
+
+	for s := 0; ; s++ {
+		if idx.probeCount(s) > idx.rows {
+			return idx.bruteForceKNN(query, k)
+		}
+
+		candidates = idx.collect(query, s, visited, candidates)
+
+		// 部分半径sまで引けば、距離が numChunks*(s+1) 未満の行は全て候補に含まれる
+		bound := numChunks * (s + 1)
+		sortNeighbors(candidates)
+		if len(candidates) >= k && candidates[k-1].Distance < bound {
+			return candidates[:k], nil
+		}
+
+		if bound > idx.cols {
+			// 全ての距離は Cols 以下なので、この時点で全件が候補に含まれている
+			return candidates[:k], nil
+		}
+	}
+}
+
+// Radiusは、queryとの距離がr以下の行を、距離が近い順に全て返す。queryは (1 x Cols) であるべき。
+func (idx *HammingIndex) Radius(query *Matrix, r int) ([]Neighbor, error) {
+	if err := idx.validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	if r < 0 {
+		return nil, fmt.Errorf("r >= 0 であるべき: r = %d", r)
+	}
+
+	var found []Neighbor
+	s := r / len(idx.tables)
+	if idx.rows < hammingIndexBruteForceRows || idx.probeCount(s) > idx.rows {
+		all, err := idx.bruteForceKNN(query, idx.rows)
+		if err != nil {
+			return nil, err
+		}
+		found = all
+	} else {
+		visited := make([]bool, idx.rows)
+		for t := 0; t <= s; t++ {
+			found = idx.collect(query, t, visited, found)
+		}
+		sortNeighbors(found)
+	}
+
+	n, _ := slices.BinarySearchFunc(found, r+1, func(nb Neighbor, d int) int {
+		return cmp.Compare(nb.Distance, d)
+	})
+	return found[:n], nil
+}
+
+func (idx *HammingIndex) validateQuery(query *Matrix) error {
+	if err := query.validateDotAVX512Family(); err != nil {
+		return err
+	}
+
+	if query.rows != 1 || query.cols != idx.cols {
+		return fmt.Errorf("queryの形状が不正: (%d x %d): (1 x %d) であるべき", query.rows, query.cols, idx.cols)
+	}
+	return nil
+}
+
+// 部分半径s以下で、全てのチャンクについて引くキーの総数。
+func (idx *HammingIndex) probeCount(s int) int {
+	count := 0
+	for _, width := range idx.chunkWidths {
+		// Σ_{i<=s} C(width, i)
+		c := 1
+		sum := 1
+		for i := 1; i <= min(s, width); i++ {
+			c = c * (width - i + 1) / i
+			sum += c
+		}
+		count += sum
+	}
+	return count
+}
+
+// 各チャンクで、queryのキーからの距離がちょうどsのキーを引き、未訪問の行の距離を求めてcandidatesへ追加する。
+// s未満のキーは、それより前の呼び出しで引かれている前提。
+func (idx *HammingIndex) collect(query *Matrix, s int, visited []bool, candidates []Neighbor) []Neighbor {
+	for i, table := range idx.tables {
+		key := idx.chunkKey(query.data, i)
+		forEachFlip(key, idx.chunkWidths[i], s, func(probe uint64) {
+			for _, row := range table[probe] {
+				if visited[row] {
+					continue
+				}
+				visited[row] = true
+				candidates = append(candidates, Neighbor{Index: row, Distance: idx.distance(query.data, row)})
+			}
+		})
+	}
+	return candidates
+}
+
+func (idx *HammingIndex) bruteForceKNN(query *Matrix, k int) ([]Neighbor, error) {
+	codes := &Matrix{rows: idx.rows, cols: idx.cols, data: idx.data, gen: new(uint64)}
+	agreements, err := query.Dot(codes)
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := make([]Neighbor, idx.rows)
+	for i, a := range agreements {
+		neighbors[i] = Neighbor{Index: i, Distance: idx.cols - a}
+	}
+	sortNeighbors(neighbors)
+	return neighbors[:k], nil
+}
+
+func (idx *HammingIndex) distance(queryRow []uint64, row int) int {
+	codeRow := idx.data[row*idx.stride : (row+1)*idx.stride]
+	return xorPopcnt(queryRow, codeRow)
+}
+
+func (idx *HammingIndex) chunkKey(row []uint64, i int) uint64 {
+	var key [1]uint64
+	copyRowBits(key[:], row, idx.chunkStarts[i])
+	width := idx.chunkWidths[i]
+	if width < 64 {
+		key[0] &= (uint64(1) << uint(width)) - 1
+	}
+	return key[0]
+}
+
+// keyの下位widthビットのうち、ちょうどs個を反転したキー全てについてfを呼ぶ。
+func forEachFlip(key uint64, width, s int, f func(uint64)) {
+	if s > width {
+		return
+	}
+
+	var rec func(key uint64, from, rest int)
+	rec = func(key uint64, from, rest int) {
+		if rest == 0 {
+			f(key)
+			return
+		}
+		for b := from; b <= width-rest; b++ {
+			rec(key^(uint64(1)<<uint(b)), b+1, rest-1)
+		}
+	}
+	rec(key, 0, s)
+}
+
+func sortNeighbors(neighbors []Neighbor) {
+	slices.SortFunc(neighbors, func(a, b Neighbor) int {
+		if c := cmp.Compare(a.Distance, b.Distance); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Index, b.Index)
+	})
+}
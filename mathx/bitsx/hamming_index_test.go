@@ -0,0 +1,166 @@
+package bitsx_test
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+// 素朴(愚直)な実装で、全件の距離を求めて並べる
+func naiveNeighbors(t *testing.T, codes []*bitsx.Matrix, query *bitsx.Matrix) []bitsx.Neighbor {
+	t.Helper()
+	neighbors := make([]bitsx.Neighbor, len(codes))
+	for i, code := range codes {
+		d, err := code.HammingDistance(query)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		neighbors[i] = bitsx.Neighbor{Index: i, Distance: d}
+	}
+	slices.SortFunc(neighbors, func(a, b bitsx.Neighbor) int {
+		if c := cmp.Compare(a.Distance, b.Distance); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Index, b.Index)
+	})
+	return neighbors
+}
+
+// centerのビットをflips回、ランダムな位置で反転した行を作る
+func newNoisyRow(t *testing.T, center *bitsx.Matrix, flips int, rng *rand.Rand) *bitsx.Matrix {
+	t.Helper()
+	row := center.Clone()
+	for range flips {
+		if err := row.Toggle(0, rng.IntN(center.Cols())); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+	}
+	return row
+}
+
+func TestHammingIndex(t *testing.T) {
+	rng := rand.New(rand.NewPCG(43, 44))
+
+	for _, cols := range []int{64, 100} {
+		// 少数の中心の周りに集まった符号を作る。ハッシュ表を引く経路と、全件比較の経路の両方を通る
+		centers := make([]*bitsx.Matrix, 8)
+		for i := range centers {
+			c, err := bitsx.NewRandMatrix(1, cols, 0, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			centers[i] = c
+		}
+
+		codes := make([]*bitsx.Matrix, 3000)
+		for i := range codes {
+			codes[i] = newNoisyRow(t, centers[rng.IntN(len(centers))], rng.IntN(12), rng)
+		}
+
+		// 最初の一部で作り、残りはAddで追加する
+		first, err := bitsx.VStack(codes[:500]...)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		idx, err := bitsx.NewHammingIndex(first)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		rest, err := bitsx.VStack(codes[500:]...)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := idx.Add(rest); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if idx.Len() != len(codes) {
+			t.Fatalf("Lenの不一致: got = %d, want = %d", idx.Len(), len(codes))
+		}
+
+		for q := range 20 {
+			var query *bitsx.Matrix
+			if q%4 == 0 {
+				// どの中心からも遠いクエリ
+				query, err = bitsx.NewRandMatrix(1, cols, 0, rng)
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+			} else {
+				query = newNoisyRow(t, centers[rng.IntN(len(centers))], rng.IntN(6), rng)
+			}
+			want := naiveNeighbors(t, codes, query)
+
+			for _, k := range []int{1, 10, 100} {
+				got, err := idx.KNN(query, k)
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				if !slices.Equal(got, want[:k]) {
+					t.Fatalf("cols = %d, k = %d: KNNの不一致: got = %v, want = %v", cols, k, got, want[:k])
+				}
+			}
+
+			for _, r := range []int{0, 3, 7, 20} {
+				got, err := idx.Radius(query, r)
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				n := 0
+				for n < len(want) && want[n].Distance <= r {
+					n++
+				}
+				if !slices.Equal(got, want[:n]) {
+					t.Fatalf("cols = %d, r = %d: Radiusの不一致: got = %d件, want = %d件", cols, r, len(got), n)
+				}
+			}
+		}
+	}
+}
+
+func TestHammingIndexSmall(t *testing.T) {
+	m, err := bitsx.ParseMatrix("[1100 1111 0000 1000]")
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	idx, err := bitsx.NewHammingIndex(m)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	query, err := bitsx.ParseMatrix("[1100]")
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	t.Run("正常_kが登録数より大きい", func(t *testing.T) {
+		got, err := idx.KNN(query, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want := []bitsx.Neighbor{{0, 0}, {3, 1}, {1, 2}, {2, 2}}
+		if !slices.Equal(got, want) {
+			t.Errorf("値の不一致: got = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("異常_不正な引数", func(t *testing.T) {
+		if _, err := idx.KNN(query, 0); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := idx.Radius(query, -1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := idx.KNN(m, 1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		other, err := bitsx.NewZerosMatrix(1, 5)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := idx.Add(other); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
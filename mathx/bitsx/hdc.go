@@ -0,0 +1,133 @@
+package bitsx
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand/v2"
+)
+
+// 超次元計算(Hyperdimensional Computing)の基本演算。
+// 各行列をハイパーベクトルとみなし、全てのビットについて独立に演算する。
+//
+//	Bind    : 排他的論理和。結合した2つのベクトルのどちらとも似ていないベクトルを作る
+//	Bundle  : ビットごとの多数決。元のベクトルのどれとも似たベクトルを作る
+//	Permute : 各行の巡回シフト。順序や位置の情報を符号化する
+
+// Bindは、全ての行列の排他的論理和を返す。
+func Bind(ms ...*Matrix) (*Matrix, error) {
+	if len(ms) == 0 {
+		return nil, fmt.Errorf("len(ms) > 0 であるべき")
+	}
+
+	bound := ms[0].Clone()
+	for _, m := range ms[1:] {
+		if err := bound.XorInPlace(m); err != nil {
+			return nil, err
+		}
+	}
+	return bound, nil
+}
+
+// Bundleは、ビットごとの多数決を返す。
+// 同数の場合、rngがnilなら0とし、そうでなければrngで0か1を選ぶ。
+func Bundle(rng *rand.Rand, ms ...*Matrix) (*Matrix, error) {
+	weights := make([]int, len(ms))
+	for i := range weights {
+		weights[i] = 1
+	}
+	return BundleWeighted(ms, weights, rng)
+}
+
+// BundleWeightedは、重み付きの多数決を返す。
+// 各ビットは、1である行列の重みの合計が、0である行列の重みの合計を上回れば1になる。
+// 負の重みは、その行列を反転して加える事に等しい。同数の場合の扱いはBundleと同じ。
+func BundleWeighted(ms []*Matrix, weights []int, rng *rand.Rand) (*Matrix, error) {
+	if len(ms) == 0 {
+		return nil, fmt.Errorf("len(ms) > 0 であるべき")
+	}
+
+	if len(weights) != len(ms) {
+		return nil, fmt.Errorf("len(weights) = len(ms) であるべき: len(weights) = %d, len(ms) = %d", len(weights), len(ms))
+	}
+
+	for i, m := range ms[1:] {
+		if err := ms[0].ValidateSameShape(m); err != nil {
+			return nil, fmt.Errorf("ms[%d]: %w", i+1, err)
+		}
+	}
+
+	// 1の重みの合計をposとすると、0の重みの合計は total - pos なので、
+	// 2*pos と total を比べれば多数決が決まる
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+
+	bundled, err := NewZerosMatrix(ms[0].rows, ms[0].cols)
+	if err != nil {
+		return nil, err
+	}
+
+	var pos [64]int
+	for i := range bundled.data {
+		clear(pos[:])
+		for j, m := range ms {
+			for word := m.data[i]; word != 0; word = ClearLowest(word) {
+				pos[bits.TrailingZeros64(word)] += weights[j]
+			}
+		}
+
+		var word, ties uint64
+		for b, p := range pos {
+			switch {
+			case 2*p > total:
+				word |= 1 << uint(b)
+			case 2*p == total:
+				ties |= 1 << uint(b)
+			}
+		}
+
+		if rng != nil && ties != 0 {
+			word |= ties & rng.Uint64()
+		}
+		bundled.data[i] = word
+	}
+
+	// 端数ビットは全ての行列で0なので、同数として扱われている場合がある
+	bundled.ApplyTailMask()
+	return bundled, nil
+}
+
+func (m *Matrix) Bind(other *Matrix) (*Matrix, error) {
+	return m.Xor(other)
+}
+
+// Permuteは、各行をk列だけ巡回シフトした行列を返す。RotateColsと同じ。
+func (m *Matrix) Permute(k int) (*Matrix, error) {
+	return m.RotateCols(k)
+}
+
+func (ms Matrices) Bind() (*Matrix, error) {
+	return Bind(ms...)
+}
+
+func (ms Matrices) Bundle(rng *rand.Rand) (*Matrix, error) {
+	return Bundle(rng, ms...)
+}
+
+func (ms Matrices) BundleWeighted(weights []int, rng *rand.Rand) (*Matrix, error) {
+	return BundleWeighted(ms, weights, rng)
+}
+
+// Permuteは、各行列をPermute(k)した結果を返す。
+func (ms Matrices) Permute(k int) (Matrices, error) {
+	permuted := make(Matrices, len(ms))
+	for i, m := range ms {
+		p, err := m.Permute(k)
+		if err != nil {
+			return nil, err
+		}
+		permuted[i] = p
+	}
+	return permuted, nil
+}
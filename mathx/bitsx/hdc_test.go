@@ -0,0 +1,200 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestPermute(t *testing.T) {
+	rng := rand.New(rand.NewPCG(47, 48))
+	for _, cols := range []int{1, 7, 64, 65, 100, 130, 192} {
+		m, err := bitsx.NewRandMatrix(3, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		for _, k := range []int{0, 1, 63, 64, 65, cols - 1, cols, 2*cols + 3, -1, -cols - 5} {
+			got, err := m.Permute(k)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			for r := range 3 {
+				for c := range cols {
+					dc := ((c+k)%cols + cols) % cols
+					if mustBit(t, got, r, dc) != mustBit(t, m, r, c) {
+						t.Fatalf("cols = %d, k = %d: (%d, %d)が(%d, %d)へ移っていない", cols, k, r, c, r, dc)
+					}
+				}
+			}
+			if got.OnesCount() != m.OnesCount() {
+				t.Fatalf("cols = %d, k = %d: 端数ビットが0に保たれていない", cols, k)
+			}
+
+			back, err := got.Permute(-k)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if !back.Equal(m) {
+				t.Fatalf("cols = %d, k = %d: Permute(-k)で元に戻らない", cols, k)
+			}
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	rng := rand.New(rand.NewPCG(49, 50))
+	ms := make(bitsx.Matrices, 3)
+	for i := range ms {
+		m, err := bitsx.NewRandMatrix(2, 70, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		ms[i] = m
+	}
+
+	bound, err := ms.Bind()
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	// Bindは自身が逆演算になる
+	unbound, err := bitsx.Bind(bound, ms[1], ms[2])
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if !unbound.Equal(ms[0]) {
+		t.Fatalf("Bindで元に戻らない")
+	}
+
+	t.Run("異常_形状の不一致", func(t *testing.T) {
+		other, err := bitsx.NewZerosMatrix(2, 71)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := ms[0].Bind(other); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestBundle(t *testing.T) {
+	parse := func(s string) *bitsx.Matrix {
+		m, err := bitsx.ParseMatrix(s)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		return m
+	}
+
+	t.Run("正常_多数決", func(t *testing.T) {
+		ms := bitsx.Matrices{parse("[1100 1010]"), parse("[1010 1000]"), parse("[1001 0110]")}
+		got, err := ms.Bundle(nil)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if want := parse("[1000 1010]"); !got.Equal(want) {
+			t.Errorf("値の不一致: got = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("正常_同数はrngがnilなら0", func(t *testing.T) {
+		ms := bitsx.Matrices{parse("[1100]"), parse("[1010]")}
+		got, err := ms.Bundle(nil)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if want := parse("[1000]"); !got.Equal(want) {
+			t.Errorf("値の不一致: got = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("正常_同数はrngで決定的に選ぶ", func(t *testing.T) {
+		a, err := bitsx.NewZerosMatrix(4, 200)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		b, err := bitsx.NewOnesMatrix(4, 200)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		got1, err := bitsx.Bundle(rand.New(rand.NewPCG(1, 2)), a, b)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got2, err := bitsx.Bundle(rand.New(rand.NewPCG(1, 2)), a, b)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !got1.Equal(got2) {
+			t.Fatalf("同じシードで結果が異なる")
+		}
+
+		// 全てのビットが同数なので、およそ半分が1になり、端数ビットは0のまま
+		ones := got1.OnesCount()
+		if ones < 300 || ones > 500 {
+			t.Errorf("1の個数が偏っている: got = %d, total = 800", ones)
+		}
+		if d, _ := got1.HammingDistance(a); d != ones {
+			t.Errorf("端数ビットが0に保たれていない")
+		}
+	})
+
+	t.Run("正常_重み付き", func(t *testing.T) {
+		ms := bitsx.Matrices{parse("[1100]"), parse("[1010]"), parse("[0001]")}
+
+		// 1つ目が他の2つの合計より重い
+		got, err := ms.BundleWeighted([]int{3, 1, 1}, nil)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if want := parse("[1100]"); !got.Equal(want) {
+			t.Errorf("値の不一致: got = %v, want = %v", got, want)
+		}
+
+		// 負の重みは反転して加える事に等しい
+		got, err = ms.BundleWeighted([]int{1, 1, -3}, nil)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if want := parse("[1110]"); !got.Equal(want) {
+			t.Errorf("値の不一致: got = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("正常_元のベクトルに似ている", func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(51, 52))
+		ms := make(bitsx.Matrices, 5)
+		for i := range ms {
+			m, err := bitsx.NewRandMatrix(1, 4096, 0, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			ms[i] = m
+		}
+		bundled, err := ms.Bundle(rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		for i, m := range ms {
+			// 無関係なベクトルとの距離は約2048。5個の多数決では約1300になる
+			if d, _ := bundled.HammingDistance(m); d > 1600 {
+				t.Errorf("%d個目との距離が大きすぎる: %d", i, d)
+			}
+		}
+	})
+
+	t.Run("異常_引数が不正", func(t *testing.T) {
+		if _, err := bitsx.Bundle(nil); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := bitsx.BundleWeighted(bitsx.Matrices{parse("[10]")}, []int{1, 2}, nil); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := bitsx.Bundle(nil, parse("[10]"), parse("[100]")); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
@@ -2,36 +2,46 @@ package bitsx
 
 import (
 	"fmt"
+	"math"
 	"math/bits"
 
 	"github.com/sw965/omw/mathx"
 )
 
 func (m *Matrix) validateDotAVX512Family() error {
+	wantDataLen, err := m.validateShape()
+	if err != nil {
+		return err
+	}
+
+	if wantDataLen != len(m.data) {
+		return fmt.Errorf("内部データ長が不正: len(data) = %d: Rows(=%d) * Stride(=%d) = %d と一致するべき",
+			len(m.data), m.rows, m.Stride(), wantDataLen)
+	}
+	return nil
+}
+
+// RowsとColsを検査し、dataのあるべき長さを返す。
+func (m *Matrix) validateShape() (dataLen int, err error) {
 	if m.rows <= 0 {
-		return fmt.Errorf("行数が不正: Rows = %d: Rows > 0 であるべき", m.rows)
+		return 0, fmt.Errorf("行数が不正: Rows = %d: Rows > 0 であるべき", m.rows)
 	}
 
 	if m.cols <= 0 {
-		return fmt.Errorf("列数が不正: Cols = %d: Cols > 0 であるべき", m.cols)
+		return 0, fmt.Errorf("列数が不正: Cols = %d: Cols > 0 であるべき", m.cols)
 	}
 
 	// 内部計算が桁あふれして負になるケースをガードする
 	stride := m.Stride()
 	if stride <= 0 {
-		return fmt.Errorf("列数が大きすぎる: Cols = %d", m.cols)
+		return 0, fmt.Errorf("列数が大きすぎる: Cols = %d", m.cols)
 	}
 
-	wantDataLen, ok := mathx.MulOverflowChecked(m.rows, stride)
+	dataLen, ok := mathx.MulOverflowChecked(m.rows, stride)
 	if !ok {
-		return fmt.Errorf("RowsとColsが大きすぎる: Rows = %d, Cols = %d", m.rows, m.cols)
+		return 0, fmt.Errorf("RowsとColsが大きすぎる: Rows = %d, Cols = %d", m.rows, m.cols)
 	}
-
-	if wantDataLen != len(m.data) {
-		return fmt.Errorf("内部データ長が不正: len(data) = %d: Rows(=%d) * Stride(=%d) = %d と一致するべき",
-			len(m.data), m.rows, stride, wantDataLen)
-	}
-	return nil
+	return dataLen, nil
 }
 
 func validateDotAVX512Args(left, right *Matrix) (resultsLen int, err error) {
@@ -82,6 +92,69 @@ func validateDotTernaryAVX512Args(value, sign, nonZero *Matrix) (resultsLen int,
 	return resultsLen, nil
 }
 
+func dotResultMax[T dotResult]() int {
+	var zero T
+	switch any(zero).(type) {
+	case int32:
+		return math.MaxInt32
+	case int16:
+		return math.MaxInt16
+	}
+	return math.MaxInt
+}
+
+// Dot・DotTernaryの結果を書き込むdstの長さと、要素型の範囲を検査する。
+func validateDotResults[T dotResult](cols, resultsLen int, dst []T) error {
+	if len(dst) != resultsLen {
+		return fmt.Errorf("結果配列の長さが不正: len(dst) = %d: %d であるべき", len(dst), resultsLen)
+	}
+
+	if maxValue := dotResultMax[T](); cols > maxValue {
+		return fmt.Errorf("列数が結果の要素型に収まらない: Cols = %d: Cols <= %d であるべき", cols, maxValue)
+	}
+	return nil
+}
+
+// maskは1行であるか、valueと同じ形状であるべき。
+func validateMaskedAVX512Args(value, other, mask *Matrix) error {
+	if err := value.ValidateSameShape(other); err != nil {
+		return err
+	}
+
+	if mask.cols != value.cols {
+		return fmt.Errorf("マスクの列数が不一致: m.Cols = %d, mask.Cols = %d", value.cols, mask.cols)
+	}
+
+	if mask.rows != 1 && mask.rows != value.rows {
+		return fmt.Errorf("マスクの行数が不正: mask.Rows = %d: 1 または %d であるべき", mask.rows, value.rows)
+	}
+
+	if err := value.validateDotAVX512Family(); err != nil {
+		return err
+	}
+
+	if err := other.validateDotAVX512Family(); err != nil {
+		return err
+	}
+	return mask.validateDotAVX512Family()
+}
+
+func validateDotMaskedAVX512Args(left, right, mask *Matrix) (resultsLen int, err error) {
+	resultsLen, err = validateDotAVX512Args(left, right)
+	if err != nil {
+		return 0, err
+	}
+
+	if mask.rows != 1 || mask.cols != left.cols {
+		return 0, fmt.Errorf("マスクの形状が不正: (%d x %d): (1 x %d) であるべき", mask.rows, mask.cols, left.cols)
+	}
+
+	if err := mask.validateDotAVX512Family(); err != nil {
+		return 0, err
+	}
+	return resultsLen, nil
+}
+
 // 端数ビットが0であることを前提とする。
 func xorPopcntGo(a, b []uint64) int {
 	sum := 0
@@ -91,20 +164,47 @@ func xorPopcntGo(a, b []uint64) int {
 	return sum
 }
 
+// Dot・DotTernaryの結果の要素型。
+// 結果の絶対値はColsを超えない為、Colsが要素型に収まれば桁あふれしない。
+type dotResult interface {
+	int | int32 | int16
+}
+
+// 端数ビットが0であることを前提とする。
+func dotGo[T dotResult](leftData, rightData []uint64, leftRows, rightRows, cols, stride int, results []T) {
+	for r := range leftRows {
+		leftRow := leftData[r*stride : (r+1)*stride]
+		resultsRow := results[r*rightRows : (r+1)*rightRows]
+		for c := range rightRows {
+			rightRow := rightData[c*stride : (c+1)*stride]
+			resultsRow[c] = T(cols - xorPopcntGo(leftRow, rightRow))
+		}
+	}
+}
+
+// 端数ビットが0であることを前提とする。
+func xorPopcntMaskedGo(a, b, mask []uint64) int {
+	sum := 0
+	for i := range a {
+		sum += bits.OnesCount64((a[i] ^ b[i]) & mask[i])
+	}
+	return sum
+}
+
 // 端数ビットが0であることを前提とする。
-func dotGo(leftData, rightData []uint64, leftRows, rightRows, cols, stride int, results []int) {
+func dotMaskedGo(leftData, rightData, maskData []uint64, leftRows, rightRows, maskOnes, stride int, results []int) {
 	for r := range leftRows {
 		leftRow := leftData[r*stride : (r+1)*stride]
 		resultsRow := results[r*rightRows : (r+1)*rightRows]
 		for c := range rightRows {
 			rightRow := rightData[c*stride : (c+1)*stride]
-			resultsRow[c] = cols - xorPopcntGo(leftRow, rightRow)
+			resultsRow[c] = maskOnes - xorPopcntMaskedGo(leftRow, rightRow, maskData)
 		}
 	}
 }
 
 // 端数ビットが0であることを前提とする。
-func dotTernaryGo(valueData, signData, nonZeroData []uint64, valueRows, signRows, stride int, results []int) {
+func dotTernaryGo[T dotResult](valueData, signData, nonZeroData []uint64, valueRows, signRows, stride int, results []T) {
 	nonZeroCounts := make([]int, signRows)
 	for c := range signRows {
 		nonZeroRow := nonZeroData[c*stride : (c+1)*stride]
@@ -125,7 +225,7 @@ func dotTernaryGo(valueData, signData, nonZeroData []uint64, valueRows, signRows
 			for k := range valueRow {
 				mismatchCount += bits.OnesCount64((valueRow[k] ^ signRow[k]) & nonZeroRow[k])
 			}
-			resultsRow[c] = nonZeroCounts[c] - 2*mismatchCount
+			resultsRow[c] = T(nonZeroCounts[c] - 2*mismatchCount)
 		}
 	}
 }
@@ -6,7 +6,10 @@ import "golang.org/x/sys/cpu"
 
 var useAVX512 = cpu.X86.HasAVX512F && cpu.X86.HasAVX512VPOPCNTDQ
 
-// 実装は kernels_amd64.s
+// AVX512が使えない環境で使う。端数ワードをPOPCNTQで数える為、POPCNTも必要とする。
+var useAVX2 = cpu.X86.HasAVX2 && cpu.X86.HasPOPCNT
+
+// 実装は kernels_amd64.s (AVX512) と kernels_avx2_amd64.s (AVX2)
 //
 //  1. Goアセンブラを呼び出す場合、各関数に書かれた制約を守らなければ、範囲外の
 //     メモリの読み書きをする恐れがある。
@@ -17,6 +20,8 @@ var useAVX512 = cpu.X86.HasAVX512F && cpu.X86.HasAVX512VPOPCNTDQ
 //     なお、番号のないコメントは、範囲外のメモリの読み書きを防ぐための制約ではなく、
 //     関数の正しい結果やその他の動作を保証するための制約を示す。
 //  4. 引数名が○○DataFirstElemとなっているものは、&Matrix.data[0]として渡される。
+//  5. ○○Int32AVX512・○○Int16AVX512(○○Int32AVX2・○○Int16AVX2)は、結果の要素型だけが異なり、
+//     制約は元の関数と同じ。結果が要素型に収まる事は、呼び出し側が保証する。
 
 // 1. n >= 0
 // 2. n <= aDataFirstElemが格納されたスライスの長さ
@@ -24,6 +29,13 @@ var useAVX512 = cpu.X86.HasAVX512F && cpu.X86.HasAVX512VPOPCNTDQ
 // 端数ビットが0であることを前提とする。
 func xorPopcntAVX512(aDataFirstElem, bDataFirstElem *uint64, n int) int
 
+// 1. n >= 0
+// 2. n <= aDataFirstElemが格納されたスライスの長さ
+// 3. n <= bDataFirstElemが格納されたスライスの長さ
+// 4. n <= maskDataFirstElemが格納されたスライスの長さ
+// 端数ビットが0であることを前提とする。
+func xorPopcntMaskedAVX512(aDataFirstElem, bDataFirstElem, maskDataFirstElem *uint64, n int) int
+
 // 1. leftRows, rightRows, stride >= 0
 // 2. leftRows*stride == leftDataFirstElemが格納されたスライスの長さ
 // 3. rightRows*stride == rightDataFirstElemが格納されたスライスの長さ
@@ -31,6 +43,10 @@ func xorPopcntAVX512(aDataFirstElem, bDataFirstElem *uint64, n int) int
 // 端数ビットが0であることを前提とする。
 func dotAVX512(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int)
 
+func dotInt32AVX512(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int32)
+
+func dotInt16AVX512(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int16)
+
 // 1. valueRows, signRows, stride >= 0
 // 2. valueRows*stride == valueDataFirstElemが格納されたスライスの長さ
 // 3. signRows*stride == signDataFirstElemが格納されたスライスの長さ
@@ -38,3 +54,45 @@ func dotAVX512(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRow
 // 5. valueRows*signRows == resultsFirstElemが格納されたスライスの長さ
 // 端数ビットが0であることを前提とする。
 func dotTernaryAVX512(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int)
+
+func dotTernaryInt32AVX512(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int32)
+
+func dotTernaryInt16AVX512(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int16)
+
+// 1. leftRows, rightRows, stride >= 0
+// 2. leftRows*stride == leftDataFirstElemが格納されたスライスの長さ
+// 3. rightRows*stride == rightDataFirstElemが格納されたスライスの長さ
+// 4. stride == maskDataFirstElemが格納されたスライスの長さ
+// 5. leftRows*rightRows == resultsFirstElemが格納されたスライスの長さ
+// maskOnesは、マスクの1の個数。
+// 端数ビットが0であることを前提とする。
+func dotMaskedAVX512(leftDataFirstElem, rightDataFirstElem, maskDataFirstElem *uint64, leftRows, rightRows, maskOnes, stride int, resultsFirstElem *int)
+
+// 1. n >= 0
+// 2. n <= aDataFirstElemが格納されたスライスの長さ
+// 3. n <= bDataFirstElemが格納されたスライスの長さ
+// 端数ビットが0であることを前提とする。
+func xorPopcntAVX2(aDataFirstElem, bDataFirstElem *uint64, n int) int
+
+// 1. leftRows, rightRows, stride >= 0
+// 2. leftRows*stride == leftDataFirstElemが格納されたスライスの長さ
+// 3. rightRows*stride == rightDataFirstElemが格納されたスライスの長さ
+// 4. leftRows*rightRows == resultsFirstElemが格納されたスライスの長さ
+// 端数ビットが0であることを前提とする。
+func dotAVX2(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int)
+
+func dotInt32AVX2(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int32)
+
+func dotInt16AVX2(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int16)
+
+// 1. valueRows, signRows, stride >= 0
+// 2. valueRows*stride == valueDataFirstElemが格納されたスライスの長さ
+// 3. signRows*stride == signDataFirstElemが格納されたスライスの長さ
+// 4. signRows*stride == nonZeroDataFirstElemが格納されたスライスの長さ
+// 5. valueRows*signRows == resultsFirstElemが格納されたスライスの長さ
+// 端数ビットが0であることを前提とする。
+func dotTernaryAVX2(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int)
+
+func dotTernaryInt32AVX2(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int32)
+
+func dotTernaryInt16AVX2(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int16)
@@ -3,6 +3,11 @@
 #include "textflag.h"
 
 // 呼び出し側が「列の端数ビットは常に0」という不変条件を保証する為、列マスク処理を省略する。
+//
+// go vet(asmdecl)はマクロを展開せずに読み、TEXTの後に書かれた行をその関数の本体とみなす。
+// その為、マクロは全てのTEXTより前に定義する。
+// またマクロの中の引数の参照(+N(FP))はasmdeclに検査されない為、マクロからは引数を参照しない。
+// 引数は各TEXTの本体でレジスタへ読み込んでからマクロを使う。
 
 // Z0 の8レーンを AX へ水平加算する(Z0/Y1/X1 を破壊)。
 #define HSUM_Z0_AX \
@@ -14,6 +19,229 @@
 	VPADDQ        X1, X0, X0; \
 	VMOVQ         X0, AX
 
+// results[r*rightRows+c] = cols - popcount(left行r ^ right行c)
+//
+// 結果の要素型ごとに、書き込み命令STOREと要素のバイト数SIZEだけが異なる為、マクロで共通化する。
+// 入力: SI = left, DI = right, R8 = leftRows, BX = rightRows, R10 = cols, R9 = stride, DX = results
+#define DOT_AVX512(STORE, SIZE) \
+	MOVQ R9, CX; \
+	ANDQ $7, CX; \
+	MOVL $1, AX; \
+	SHLL CX, AX; \
+	DECL AX; \
+	KMOVB AX, K1; \
+leftRowLoop: \
+	TESTQ R8, R8; \
+	JEQ   dotDone; \
+	MOVQ DI, R11; \
+	MOVQ BX, R12; \
+rightRowLoop: \
+	TESTQ R12, R12; \
+	JEQ   nextLeftRow; \
+	MOVQ SI, R13; \
+	MOVQ R9, CX; \
+	VPXORQ Z0, Z0, Z0; \
+dotWordLoop: \
+	CMPQ CX, $8; \
+	JLT  dotWordTail; \
+	VMOVDQU64 (R13), Z1; \
+	VPXORQ (R11), Z1, Z1; \
+	VPOPCNTQ Z1, Z1; \
+	VPADDQ Z1, Z0, Z0; \
+	ADDQ $64, R13; \
+	ADDQ $64, R11; \
+	SUBQ $8, CX; \
+	JMP  dotWordLoop; \
+dotWordTail: \
+	TESTQ CX, CX; \
+	JEQ   dotReduce; \
+	VMOVDQU64.Z (R13), K1, Z1; \
+	VMOVDQU64.Z (R11), K1, Z2; \
+	VPXORQ Z2, Z1, Z1; \
+	VPOPCNTQ Z1, Z1; \
+	VPADDQ Z1, Z0, Z0; \
+	LEAQ (R11)(CX*8), R11; \
+dotReduce: \
+	HSUM_Z0_AX; \
+	MOVQ R10, R14; \
+	SUBQ AX, R14; \
+	STORE R14, (DX); \
+	ADDQ $SIZE, DX; \
+	DECQ R12; \
+	JMP  rightRowLoop; \
+nextLeftRow: \
+	LEAQ (SI)(R9*8), SI; \
+	DECQ R8; \
+	JMP  leftRowLoop; \
+dotDone: \
+	VZEROUPPER; \
+	RET
+
+// results[r*signRows+c] = popcount(nonZero行c) - 2*popcount((value行r ^ sign行c) & nonZero行c)
+//
+// popcount(nonZero行) は value行 に依存しない為、ブロックごとに事前計算して value行 のループで使い回す。
+//
+// ただし内側を value行 にすると results の書き込みが signRows*8 バイト刻みになり、
+// signRows が512の倍数でL1のセット競合とTLBミスを起こす
+// (Zen4実測 valueRows=768, cols=768, signRows=512: 2.6 → 5.4 ns/結果)。
+// その為 sign行 を8行ずつ処理し、内側で results の連続8個(=1キャッシュライン以内)を書き切る。
+// 書き込みは同一キャッシュラインへ連続する(ブロック化の狙い)。
+//
+// valueRows=1 の場合は、求めた popcount(nonZero行) を1回しか使わない為、
+// 事前に求めず1回の走査で両方求める実装より遅い。
+//
+// 結果の要素型ごとに、書き込み命令STORE、要素のバイト数SCALE、log2(SCALE)であるSHIFTだけが異なる為、
+// マクロで共通化する。
+//
+// 汎用レジスタ13本に空きが無い為、8個の popcount(nonZero行) はフレームに置き、
+// 各レジスタの役割には名前を付ける(AXのみ一時用)。
+// 不変
+#define mainBytes    SI
+#define strideBytes  R9
+#define nzCountBase  DI
+
+// 走査で進む
+#define restSignRows BX
+#define blockSign    R12
+#define blockNz      R13
+#define curValue     R10
+#define curRes       R11
+#define curSign      R14
+#define curNz        DX
+
+// ブロック内の sign行 の番号 (nzCounts は8バイト、results はSCALEバイト刻みで参照する)
+#define colIdx       R8
+
+// バイトオフセット
+#define byteOff      CX
+
+// マスク生成の間だけ CX に端数ワード数が入る(可変シフト量はCLでなければならない為)。
+#define tailWords    CX
+
+// フレーム104バイト(空き無し)
+//   valueBase -104 / nzCounts -96..-40 (8スロット) / valueEnd -32 / resRowBytes -24 / resColBase -16 / blockRows -8
+//
+// 入力: curValue = value, blockSign = sign, blockNz = nonZero, colIdx = valueRows,
+//       restSignRows = signRows, AX = stride, curRes = results
+#define DOT_TERNARY_AVX512(STORE, SCALE, SHIFT) \
+	MOVQ curValue, valueBase-104(SP); \
+	MOVQ AX, strideBytes; \
+	SHLQ $3, strideBytes; \
+	MOVQ AX, mainBytes; \
+	SHLQ $3, mainBytes; \
+	ANDQ $-64, mainBytes; \
+	MOVQ AX, tailWords; \
+	ANDQ $7, tailWords; \
+	MOVL $1, AX; \
+	SHLL tailWords, AX; \
+	DECL AX; \
+	KMOVB AX, K1; \
+	MOVQ colIdx, AX; \
+	IMULQ strideBytes, AX; \
+	ADDQ curValue, AX; \
+	MOVQ AX, valueEnd-32(SP); \
+	MOVQ restSignRows, AX; \
+	SHLQ $SHIFT, AX; \
+	MOVQ AX, resRowBytes-24(SP); \
+	MOVQ curRes, resColBase-16(SP); \
+	LEAQ nzCounts-96(SP), nzCountBase; \
+ternaryBlockLoop: \
+	TESTQ restSignRows, restSignRows; \
+	JEQ   ternaryDone; \
+	MOVQ restSignRows, AX; \
+	CMPQ AX, $8; \
+	JLE  ternaryBlockSizeOK; \
+	MOVQ $8, AX; \
+ternaryBlockSizeOK: \
+	MOVQ AX, blockRows-8(SP); \
+	MOVQ blockNz, curNz; \
+	XORQ colIdx, colIdx; \
+ternaryNzRowLoop: \
+	CMPQ colIdx, blockRows-8(SP); \
+	JGE  ternaryNzDone; \
+	VPXORQ Z0, Z0, Z0; \
+	XORQ  byteOff, byteOff; \
+ternaryNzWordLoop: \
+	CMPQ byteOff, mainBytes; \
+	JGE  ternaryNzWordTail; \
+	VPOPCNTQ (curNz)(byteOff*1), Z1; \
+	VPADDQ Z1, Z0, Z0; \
+	ADDQ $64, byteOff; \
+	JMP  ternaryNzWordLoop; \
+ternaryNzWordTail: \
+	CMPQ byteOff, strideBytes; \
+	JGE  ternaryNzReduce; \
+	VMOVDQU64.Z (curNz)(byteOff*1), K1, Z1; \
+	VPOPCNTQ Z1, Z1; \
+	VPADDQ Z1, Z0, Z0; \
+ternaryNzReduce: \
+	HSUM_Z0_AX; \
+	MOVQ AX, (nzCountBase)(colIdx*8); \
+	ADDQ strideBytes, curNz; \
+	INCQ colIdx; \
+	JMP  ternaryNzRowLoop; \
+ternaryNzDone: \
+	MOVQ valueBase-104(SP), curValue; \
+	MOVQ resColBase-16(SP), curRes; \
+ternaryValueRowLoop: \
+	CMPQ curValue, valueEnd-32(SP); \
+	JCC  ternaryNextBlock; \
+	MOVQ blockSign, curSign; \
+	MOVQ blockNz, curNz; \
+	XORQ colIdx, colIdx; \
+ternaryColLoop: \
+	CMPQ colIdx, blockRows-8(SP); \
+	JGE  ternaryNextValueRow; \
+	VPXORQ Z0, Z0, Z0; \
+	XORQ  byteOff, byteOff; \
+ternaryWordLoop: \
+	CMPQ byteOff, mainBytes; \
+	JGE  ternaryWordTail; \
+	VMOVDQU64 (curValue)(byteOff*1), Z1; \
+	VPXORQ (curSign)(byteOff*1), Z1, Z1; \
+	VPANDQ (curNz)(byteOff*1), Z1, Z1; \
+	VPOPCNTQ Z1, Z1; \
+	VPADDQ Z1, Z0, Z0; \
+	ADDQ $64, byteOff; \
+	JMP  ternaryWordLoop; \
+ternaryWordTail: \
+	CMPQ byteOff, strideBytes; \
+	JGE  ternaryReduce; \
+	VMOVDQU64.Z (curValue)(byteOff*1), K1, Z1; \
+	VMOVDQU64.Z (curSign)(byteOff*1), K1, Z2; \
+	VPXORQ Z2, Z1, Z1; \
+	VMOVDQU64.Z (curNz)(byteOff*1), K1, Z2; \
+	VPANDQ Z2, Z1, Z1; \
+	VPOPCNTQ Z1, Z1; \
+	VPADDQ Z1, Z0, Z0; \
+ternaryReduce: \
+	HSUM_Z0_AX; \
+	SHLQ $1, AX; \
+	NEGQ AX; \
+	ADDQ (nzCountBase)(colIdx*8), AX; \
+	STORE AX, (curRes)(colIdx*SCALE); \
+	ADDQ strideBytes, curSign; \
+	ADDQ strideBytes, curNz; \
+	INCQ colIdx; \
+	JMP  ternaryColLoop; \
+ternaryNextValueRow: \
+	ADDQ resRowBytes-24(SP), curRes; \
+	ADDQ strideBytes, curValue; \
+	JMP  ternaryValueRowLoop; \
+ternaryNextBlock: \
+	MOVQ blockRows-8(SP), AX; \
+	SUBQ AX, restSignRows; \
+	SHLQ $SHIFT, AX; \
+	ADDQ AX, resColBase-16(SP); \
+	MOVQ blockRows-8(SP), AX; \
+	IMULQ strideBytes, AX; \
+	ADDQ AX, blockSign; \
+	ADDQ AX, blockNz; \
+	JMP  ternaryBlockLoop; \
+ternaryDone: \
+	VZEROUPPER; \
+	RET
+
 // func xorPopcntAVX512(aDataFirstElem, bDataFirstElem *uint64, n int) int
 TEXT ·xorPopcntAVX512(SB), NOSPLIT, $0-32
 	MOVQ aDataFirstElem+0(FP), SI
@@ -52,261 +280,191 @@ reduce:
 	VZEROUPPER
 	RET
 
-// func dotAVX512(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int)
+// func xorPopcntMaskedAVX512(aDataFirstElem, bDataFirstElem, maskDataFirstElem *uint64, n int) int
 //
-// results[r*rightRows+c] = cols - popcount(left行r ^ right行c)
-TEXT ·dotAVX512(SB), NOSPLIT, $0-56
-	MOVQ leftDataFirstElem+0(FP), SI
-	MOVQ rightDataFirstElem+8(FP), DI
-	MOVQ leftRows+16(FP), R8
-	MOVQ rightRows+24(FP), BX
-	MOVQ cols+32(FP), R10
-	MOVQ stride+40(FP), R9
-	MOVQ resultsFirstElem+48(FP), DX
-
-	MOVQ R9, CX
-	ANDQ $7, CX
-	MOVL $1, AX
-	SHLL CX, AX
-	DECL AX
-	KMOVB AX, K1
-
-leftRowLoop:
-	TESTQ R8, R8
-	JEQ   dotDone
-	MOVQ DI, R11
-	MOVQ BX, R12
-
-rightRowLoop:
-	TESTQ R12, R12
-	JEQ   nextLeftRow
-	MOVQ SI, R13
-	MOVQ R9, CX
+// popcount((a ^ b) & mask)
+TEXT ·xorPopcntMaskedAVX512(SB), NOSPLIT, $0-40
+	MOVQ aDataFirstElem+0(FP), SI
+	MOVQ bDataFirstElem+8(FP), DI
+	MOVQ maskDataFirstElem+16(FP), R8
+	MOVQ n+24(FP), CX
 	VPXORQ Z0, Z0, Z0
 
-dotWordLoop:
+maskedLoop:
 	CMPQ CX, $8
-	JLT  dotWordTail
-	VMOVDQU64 (R13), Z1
-	VPXORQ (R11), Z1, Z1
+	JLT  maskedTail
+	VMOVDQU64 (SI), Z1
+	VPXORQ (DI), Z1, Z1
+	VPANDQ (R8), Z1, Z1
 	VPOPCNTQ Z1, Z1
 	VPADDQ Z1, Z0, Z0
-	ADDQ $64, R13
-	ADDQ $64, R11
+	ADDQ $64, SI
+	ADDQ $64, DI
+	ADDQ $64, R8
 	SUBQ $8, CX
-	JMP  dotWordLoop
+	JMP  maskedLoop
 
-dotWordTail:
+maskedTail:
 	TESTQ CX, CX
-	JEQ   dotReduce
-	VMOVDQU64.Z (R13), K1, Z1
-	VMOVDQU64.Z (R11), K1, Z2
+	JEQ   maskedReduce
+	MOVL  $1, AX
+	SHLL  CX, AX
+	DECL  AX
+	KMOVB AX, K1
+	VMOVDQU64.Z (SI), K1, Z1
+	VMOVDQU64.Z (DI), K1, Z2
 	VPXORQ Z2, Z1, Z1
+	VMOVDQU64.Z (R8), K1, Z2
+	VPANDQ Z2, Z1, Z1
 	VPOPCNTQ Z1, Z1
 	VPADDQ Z1, Z0, Z0
-	LEAQ (R11)(CX*8), R11
 
-dotReduce:
+maskedReduce:
 	HSUM_Z0_AX
-	MOVQ R10, R14
-	SUBQ AX, R14
-	MOVQ R14, (DX)
-	ADDQ $8, DX
-	DECQ R12
-	JMP  rightRowLoop
-
-nextLeftRow:
-	LEAQ (SI)(R9*8), SI
-	DECQ R8
-	JMP  leftRowLoop
-
-dotDone:
+	MOVQ AX, ret+32(FP)
 	VZEROUPPER
 	RET
 
-// func dotTernaryAVX512(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int)
-//
-// results[r*signRows+c] = popcount(nonZero行c) - 2*popcount((value行r ^ sign行c) & nonZero行c)
-//
-// popcount(nonZero行) は value行 に依存しない為、ブロックごとに事前計算して value行 のループで使い回す。
-//
-// ただし内側を value行 にすると results の書き込みが signRows*8 バイト刻みになり、
-// signRows が512の倍数でL1のセット競合とTLBミスを起こす
-// (Zen4実測 valueRows=768, cols=768, signRows=512: 2.6 → 5.4 ns/結果)。
-// その為 sign行 を8行ずつ処理し、内側で results の連続8個(=1キャッシュライン)を書き切る。
-//
-// valueRows=1 の場合は、求めた popcount(nonZero行) を1回しか使わない為、
-// 事前に求めず1回の走査で両方求める実装より遅い。
-//
-// 汎用レジスタ13本に空きが無い為、8個の popcount(nonZero行) はフレームに置き、
-// 各レジスタの役割には名前を付ける(AXのみ一時用)。
-// 不変
-#define mainBytes    SI
-#define strideBytes  R9
-#define nzCountBase  DI
+// func dotAVX512(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int)
+TEXT ·dotAVX512(SB), NOSPLIT, $0-56
+	MOVQ leftDataFirstElem+0(FP), SI
+	MOVQ rightDataFirstElem+8(FP), DI
+	MOVQ leftRows+16(FP), R8
+	MOVQ rightRows+24(FP), BX
+	MOVQ cols+32(FP), R10
+	MOVQ stride+40(FP), R9
+	MOVQ resultsFirstElem+48(FP), DX
+	DOT_AVX512(MOVQ, 8)
 
-// 走査で進む
-#define restSignRows BX
-#define blockSign    R12
-#define blockNz      R13
-#define curValue     R10
-#define curRes       R11
-#define curSign      R14
-#define curNz        DX
+// func dotInt32AVX512(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int32)
+TEXT ·dotInt32AVX512(SB), NOSPLIT, $0-56
+	MOVQ leftDataFirstElem+0(FP), SI
+	MOVQ rightDataFirstElem+8(FP), DI
+	MOVQ leftRows+16(FP), R8
+	MOVQ rightRows+24(FP), BX
+	MOVQ cols+32(FP), R10
+	MOVQ stride+40(FP), R9
+	MOVQ resultsFirstElem+48(FP), DX
+	DOT_AVX512(MOVL, 4)
 
-// バイトオフセット
-#define colOff       R8
-#define byteOff      CX
+// func dotInt16AVX512(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int16)
+TEXT ·dotInt16AVX512(SB), NOSPLIT, $0-56
+	MOVQ leftDataFirstElem+0(FP), SI
+	MOVQ rightDataFirstElem+8(FP), DI
+	MOVQ leftRows+16(FP), R8
+	MOVQ rightRows+24(FP), BX
+	MOVQ cols+32(FP), R10
+	MOVQ stride+40(FP), R9
+	MOVQ resultsFirstElem+48(FP), DX
+	DOT_AVX512(MOVW, 2)
 
-// マスク生成の間だけ CX に端数ワード数が入る(可変シフト量はCLでなければならない為)。
-#define tailWords    CX
+// func dotTernaryAVX512(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int)
+TEXT ·dotTernaryAVX512(SB), NOSPLIT, $104-56
+	MOVQ valueDataFirstElem+0(FP), curValue
+	MOVQ signDataFirstElem+8(FP), blockSign
+	MOVQ nonZeroDataFirstElem+16(FP), blockNz
+	MOVQ valueRows+24(FP), colIdx
+	MOVQ signRows+32(FP), restSignRows
+	MOVQ stride+40(FP), AX
+	MOVQ resultsFirstElem+48(FP), curRes
+	DOT_TERNARY_AVX512(MOVQ, 8, 3)
 
-// フレーム96バイト(空き無し)
-//   nzCounts -96..-40 (8スロット) / valueEnd -32 / resRowBytes -24 / resColBase -16 / blockBytes -8
-TEXT ·dotTernaryAVX512(SB), NOSPLIT, $96-56
+// func dotTernaryInt32AVX512(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int32)
+TEXT ·dotTernaryInt32AVX512(SB), NOSPLIT, $104-56
+	MOVQ valueDataFirstElem+0(FP), curValue
 	MOVQ signDataFirstElem+8(FP), blockSign
 	MOVQ nonZeroDataFirstElem+16(FP), blockNz
+	MOVQ valueRows+24(FP), colIdx
 	MOVQ signRows+32(FP), restSignRows
-	MOVQ stride+40(FP), AX   // AX = stride (ワード数)
+	MOVQ stride+40(FP), AX
+	MOVQ resultsFirstElem+48(FP), curRes
+	DOT_TERNARY_AVX512(MOVL, 4, 2)
 
-	MOVQ AX, strideBytes
-	SHLQ $3, strideBytes
+// func dotTernaryInt16AVX512(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int16)
+TEXT ·dotTernaryInt16AVX512(SB), NOSPLIT, $104-56
+	MOVQ valueDataFirstElem+0(FP), curValue
+	MOVQ signDataFirstElem+8(FP), blockSign
+	MOVQ nonZeroDataFirstElem+16(FP), blockNz
+	MOVQ valueRows+24(FP), colIdx
+	MOVQ signRows+32(FP), restSignRows
+	MOVQ stride+40(FP), AX
+	MOVQ resultsFirstElem+48(FP), curRes
+	DOT_TERNARY_AVX512(MOVW, 2, 1)
 
-	MOVQ AX, mainBytes
-	SHLQ $3, mainBytes
-	ANDQ $-64, mainBytes     // 8ワード(=64バイト)単位に切り捨て
+// func dotMaskedAVX512(leftDataFirstElem, rightDataFirstElem, maskDataFirstElem *uint64, leftRows, rightRows, maskOnes, stride int, resultsFirstElem *int)
+//
+// results[r*rightRows+c] = maskOnes - popcount((left行r ^ right行c) & mask)
+//
+// maskは全ての行の組で共通の1行。dotAVX512と同じ順で走査し、ワードはバイトオフセットで参照する。
+TEXT ·dotMaskedAVX512(SB), NOSPLIT, $0-64
+	MOVQ leftDataFirstElem+0(FP), SI
+	MOVQ rightDataFirstElem+8(FP), DI
+	MOVQ maskDataFirstElem+16(FP), R13
+	MOVQ leftRows+24(FP), R8
+	MOVQ rightRows+32(FP), BX
+	MOVQ maskOnes+40(FP), R10
+	MOVQ stride+48(FP), R9
+	MOVQ resultsFirstElem+56(FP), DX
 
-	MOVQ AX, tailWords
-	ANDQ $7, tailWords
+	MOVQ R9, CX
+	ANDQ $7, CX
 	MOVL $1, AX
-	SHLL tailWords, AX
+	SHLL CX, AX
 	DECL AX
 	KMOVB AX, K1
 
-	MOVQ valueRows+24(FP), AX
-	IMULQ strideBytes, AX
-	ADDQ valueDataFirstElem+0(FP), AX
-	MOVQ AX, valueEnd-32(SP)
-
-	MOVQ restSignRows, AX
-	SHLQ $3, AX
-	MOVQ AX, resRowBytes-24(SP)
-
-	MOVQ resultsFirstElem+48(FP), AX
-	MOVQ AX, resColBase-16(SP)
-
-	LEAQ nzCounts-96(SP), nzCountBase
-
-ternaryBlockLoop:
-	TESTQ restSignRows, restSignRows
-	JEQ   ternaryDone
-	MOVQ restSignRows, AX
-	CMPQ AX, $8
-	JLE  ternaryBlockSizeOK
-	MOVQ $8, AX
-
-ternaryBlockSizeOK:
-	SHLQ $3, AX
-	MOVQ AX, blockBytes-8(SP)
-	MOVQ blockNz, curNz
-	XORQ colOff, colOff
-
-ternaryNzRowLoop:
-	CMPQ colOff, blockBytes-8(SP)
-	JGE  ternaryNzDone
-	VPXORQ Z0, Z0, Z0
-	XORQ  byteOff, byteOff
-
-ternaryNzWordLoop:
-	CMPQ byteOff, mainBytes
-	JGE  ternaryNzWordTail
-	VPOPCNTQ (curNz)(byteOff*1), Z1
-	VPADDQ Z1, Z0, Z0
-	ADDQ $64, byteOff
-	JMP  ternaryNzWordLoop
+	SHLQ $3, R9              // R9 = strideBytes
 
-ternaryNzWordTail:
-	CMPQ byteOff, strideBytes
-	JGE  ternaryNzReduce
-	VMOVDQU64.Z (curNz)(byteOff*1), K1, Z1
-	VPOPCNTQ Z1, Z1
-	VPADDQ Z1, Z0, Z0
-
-ternaryNzReduce:
-	HSUM_Z0_AX
-	MOVQ AX, (nzCountBase)(colOff*1)
-	ADDQ strideBytes, curNz
-	ADDQ $8, colOff
-	JMP  ternaryNzRowLoop
+maskedLeftRowLoop:
+	TESTQ R8, R8
+	JEQ   maskedDotDone
+	MOVQ DI, R11
+	MOVQ BX, R12
 
-ternaryNzDone:
-	MOVQ valueDataFirstElem+0(FP), curValue
-	MOVQ resColBase-16(SP), curRes
-
-ternaryValueRowLoop:
-	CMPQ curValue, valueEnd-32(SP)
-	JCC  ternaryNextBlock
-	MOVQ blockSign, curSign
-	MOVQ blockNz, curNz
-	XORQ colOff, colOff
-
-ternaryColLoop:
-	CMPQ colOff, blockBytes-8(SP)
-	JGE  ternaryNextValueRow
+maskedRightRowLoop:
+	TESTQ R12, R12
+	JEQ   maskedNextLeftRow
+	XORQ CX, CX
 	VPXORQ Z0, Z0, Z0
-	XORQ  byteOff, byteOff
-
-ternaryWordLoop:
-	CMPQ byteOff, mainBytes
-	JGE  ternaryWordTail
-	VMOVDQU64 (curValue)(byteOff*1), Z1
-	VPXORQ (curSign)(byteOff*1), Z1, Z1
-	VPANDQ (curNz)(byteOff*1), Z1, Z1
+
+maskedDotWordLoop:
+	LEAQ 64(CX), AX
+	CMPQ AX, R9
+	JGT  maskedDotWordTail
+	VMOVDQU64 (SI)(CX*1), Z1
+	VPXORQ (R11)(CX*1), Z1, Z1
+	VPANDQ (R13)(CX*1), Z1, Z1
 	VPOPCNTQ Z1, Z1
 	VPADDQ Z1, Z0, Z0
-	ADDQ $64, byteOff
-	JMP  ternaryWordLoop
-
-ternaryWordTail:
-	CMPQ byteOff, strideBytes
-	JGE  ternaryReduce
-	VMOVDQU64.Z (curValue)(byteOff*1), K1, Z1
-	VMOVDQU64.Z (curSign)(byteOff*1), K1, Z2
+	MOVQ AX, CX
+	JMP  maskedDotWordLoop
+
+maskedDotWordTail:
+	CMPQ CX, R9
+	JGE  maskedDotReduce
+	VMOVDQU64.Z (SI)(CX*1), K1, Z1
+	VMOVDQU64.Z (R11)(CX*1), K1, Z2
 	VPXORQ Z2, Z1, Z1
-	VMOVDQU64.Z (curNz)(byteOff*1), K1, Z2
+	VMOVDQU64.Z (R13)(CX*1), K1, Z2
 	VPANDQ Z2, Z1, Z1
 	VPOPCNTQ Z1, Z1
 	VPADDQ Z1, Z0, Z0
 
-ternaryReduce:
+maskedDotReduce:
 	HSUM_Z0_AX
-	SHLQ $1, AX
-	NEGQ AX
-	ADDQ (nzCountBase)(colOff*1), AX
-
-	// ブロック化の狙い。同一キャッシュラインへ連続して書き込む
-	MOVQ AX, (curRes)(colOff*1)
-
-	ADDQ strideBytes, curSign
-	ADDQ strideBytes, curNz
-	ADDQ $8, colOff
-	JMP  ternaryColLoop
-
-ternaryNextValueRow:
-	ADDQ resRowBytes-24(SP), curRes
-	ADDQ strideBytes, curValue
-	JMP  ternaryValueRowLoop
-
-ternaryNextBlock:
-	MOVQ blockBytes-8(SP), AX
-	ADDQ AX, resColBase-16(SP)
-	SHRQ $3, AX              // AX = このブロックで処理した行数
-	SUBQ AX, restSignRows
-	IMULQ strideBytes, AX
-	ADDQ AX, blockSign
-	ADDQ AX, blockNz
-	JMP  ternaryBlockLoop
-
-ternaryDone:
+	MOVQ R10, R14
+	SUBQ AX, R14
+	MOVQ R14, (DX)
+	ADDQ $8, DX
+	ADDQ R9, R11
+	DECQ R12
+	JMP  maskedRightRowLoop
+
+maskedNextLeftRow:
+	ADDQ R9, SI
+	DECQ R8
+	JMP  maskedLeftRowLoop
+
+maskedDotDone:
 	VZEROUPPER
 	RET
@@ -0,0 +1,334 @@
+//go:build amd64
+
+#include "textflag.h"
+
+// AVX2にはVPOPCNTQが無い為、4ビットごとの1の個数の表をVPSHUFBで引いてバイト単位のpopcountを求め、
+// VPSADBWで64ビットレーンへ集約する。
+//
+// kernels_amd64.s と同様に、呼び出し側が「列の端数ビットは常に0」という不変条件を保証する為、
+// 列マスク処理を省略する。また、マクロは全てのTEXTより前に定義し、マクロからは引数(+N(FP))を参照しない。
+//
+// 4ワード(1ベクトル)に満たない端数は、範囲外を読まない様に1ワードずつPOPCNTQで数える。
+//
+// レジスタ: Y15 = 表, Y14 = 下位4ビットのマスク, Y13 = 0 (全関数で不変)
+
+DATA nibblePopcntLUT<>+0(SB)/8, $0x0302020102010100
+DATA nibblePopcntLUT<>+8(SB)/8, $0x0403030203020201
+DATA nibblePopcntLUT<>+16(SB)/8, $0x0302020102010100
+DATA nibblePopcntLUT<>+24(SB)/8, $0x0403030203020201
+GLOBL nibblePopcntLUT<>(SB), RODATA|NOPTR, $32
+
+DATA nibbleMask<>+0(SB)/8, $0x0f0f0f0f0f0f0f0f
+DATA nibbleMask<>+8(SB)/8, $0x0f0f0f0f0f0f0f0f
+DATA nibbleMask<>+16(SB)/8, $0x0f0f0f0f0f0f0f0f
+DATA nibbleMask<>+24(SB)/8, $0x0f0f0f0f0f0f0f0f
+GLOBL nibbleMask<>(SB), RODATA|NOPTR, $32
+
+#define NIBBLE_INIT \
+	VMOVDQU nibblePopcntLUT<>(SB), Y15; \
+	VMOVDQU nibbleMask<>(SB), Y14; \
+	VPXOR   Y13, Y13, Y13
+
+// Vの各バイトのpopcountを、ACCの各バイトへ加算する(V/Y4 を破壊)。
+// 1回で各バイトに加わるのは高々8なので、VPSADBWで集約するまでに31回まで重ねられる。
+#define NIBBLE_POPCNT(V, ACC) \
+	VPSRLW  $4, V, Y4; \
+	VPAND   Y14, V, V; \
+	VPAND   Y14, Y4, Y4; \
+	VPSHUFB V, Y15, V; \
+	VPSHUFB Y4, Y15, Y4; \
+	VPADDB  V, ACC, ACC; \
+	VPADDB  Y4, ACC, ACC
+
+// ACCのバイト単位の和を、QACCの64ビットレーンへ加算する(ACC を破壊)。
+#define FLUSH_BYTES(ACC, QACC) \
+	VPSADBW Y13, ACC, ACC; \
+	VPADDQ  ACC, QACC, QACC
+
+// Yの4レーンをOUTへ水平加算する(Y/Y1 を破壊)。XはYの下位128ビット。
+#define HSUM_AVX2(Y, X, OUT) \
+	VEXTRACTI128 $1, Y, X1; \
+	VPADDQ       X1, X, X; \
+	VPSRLDQ      $8, X, X1; \
+	VPADDQ       X1, X, X; \
+	VMOVQ        X, OUT
+
+// OUT = popcount(A[0:N] ^ B[0:N])
+//
+// A・Bは読んだ分だけ進み、終了時には N ワード先を指す。N は0になる。
+// 16ワード(4ベクトル)ごとにバイト単位で重ねてから集約する。
+#define XOR_POPCNT_AVX2(A, B, N, OUT, TMP) \
+	VPXOR Y0, Y0, Y0; \
+	XORQ  OUT, OUT; \
+xorBlockLoop: \
+	CMPQ N, $16; \
+	JLT  xorVectorLoop; \
+	VPXOR   Y2, Y2, Y2; \
+	VMOVDQU (A), Y3; \
+	VPXOR   (B), Y3, Y3; \
+	NIBBLE_POPCNT(Y3, Y2); \
+	VMOVDQU 32(A), Y3; \
+	VPXOR   32(B), Y3, Y3; \
+	NIBBLE_POPCNT(Y3, Y2); \
+	VMOVDQU 64(A), Y3; \
+	VPXOR   64(B), Y3, Y3; \
+	NIBBLE_POPCNT(Y3, Y2); \
+	VMOVDQU 96(A), Y3; \
+	VPXOR   96(B), Y3, Y3; \
+	NIBBLE_POPCNT(Y3, Y2); \
+	FLUSH_BYTES(Y2, Y0); \
+	ADDQ $128, A; \
+	ADDQ $128, B; \
+	SUBQ $16, N; \
+	JMP  xorBlockLoop; \
+xorVectorLoop: \
+	CMPQ N, $4; \
+	JLT  xorWordLoop; \
+	VPXOR   Y2, Y2, Y2; \
+	VMOVDQU (A), Y3; \
+	VPXOR   (B), Y3, Y3; \
+	NIBBLE_POPCNT(Y3, Y2); \
+	FLUSH_BYTES(Y2, Y0); \
+	ADDQ $32, A; \
+	ADDQ $32, B; \
+	SUBQ $4, N; \
+	JMP  xorVectorLoop; \
+xorWordLoop: \
+	TESTQ N, N; \
+	JEQ   xorReduce; \
+	MOVQ    (A), TMP; \
+	XORQ    (B), TMP; \
+	POPCNTQ TMP, TMP; \
+	ADDQ    TMP, OUT; \
+	ADDQ $8, A; \
+	ADDQ $8, B; \
+	DECQ N; \
+	JMP  xorWordLoop; \
+xorReduce: \
+	HSUM_AVX2(Y0, X0, TMP); \
+	ADDQ TMP, OUT
+
+// results[r*rightRows+c] = cols - popcount(left行r ^ right行c)
+//
+// XOR_POPCNT_AVX2がright行のポインタをstrideワード進める為、そのまま次のright行を指す。
+// 結果の要素型ごとに、書き込み命令STOREと要素のバイト数SIZEだけが異なる為、マクロで共通化する。
+// 入力: SI = left, DI = right, R8 = leftRows, BX = rightRows, R10 = cols, R9 = stride, DX = results
+#define DOT_AVX2(STORE, SIZE) \
+	NIBBLE_INIT; \
+leftRowLoop: \
+	TESTQ R8, R8; \
+	JEQ   dotDone; \
+	MOVQ DI, R11; \
+	MOVQ BX, R12; \
+rightRowLoop: \
+	TESTQ R12, R12; \
+	JEQ   nextLeftRow; \
+	MOVQ SI, R13; \
+	MOVQ R9, CX; \
+	XOR_POPCNT_AVX2(R13, R11, CX, AX, R14); \
+	MOVQ R10, R14; \
+	SUBQ AX, R14; \
+	STORE R14, (DX); \
+	ADDQ $SIZE, DX; \
+	DECQ R12; \
+	JMP  rightRowLoop; \
+nextLeftRow: \
+	LEAQ (SI)(R9*8), SI; \
+	DECQ R8; \
+	JMP  leftRowLoop; \
+dotDone: \
+	VZEROUPPER; \
+	RET
+
+// results[r*signRows+c] = popcount(nonZero行c) - 2*popcount((value行r ^ sign行c) & nonZero行c)
+//
+// nonZero行はANDの為に読み込み済みなので、popcount(nonZero行)は事前に求めず、同じ走査で数える。
+// Y2/Y0 が不一致の数、Y5/Y6 が非零の数のバイト単位/64ビットレーンの和。
+// sign行とnonZero行のポインタはstrideワード進む為、そのまま次の行を指す。
+//
+// 入力: SI = value, R11 = sign, R12 = nonZero, R8 = valueRows, BX = signRows, R9 = stride, DX = results
+// フレーム16バイト: signBase -16 / nzBase -8 (value行ごとに、sign・nonZeroの先頭へ戻る為)
+#define DOT_TERNARY_AVX2(STORE, SIZE) \
+	MOVQ R11, signBase-16(SP); \
+	MOVQ R12, nzBase-8(SP); \
+	NIBBLE_INIT; \
+ternaryValueRowLoop: \
+	TESTQ R8, R8; \
+	JEQ   ternaryDone; \
+	MOVQ signBase-16(SP), R11; \
+	MOVQ nzBase-8(SP), R12; \
+	MOVQ BX, R10; \
+ternarySignRowLoop: \
+	TESTQ R10, R10; \
+	JEQ   ternaryNextValueRow; \
+	MOVQ SI, R13; \
+	MOVQ R9, CX; \
+	VPXOR Y0, Y0, Y0; \
+	VPXOR Y6, Y6, Y6; \
+	XORQ  AX, AX; \
+	XORQ  R14, R14; \
+ternaryBlockLoop: \
+	CMPQ CX, $16; \
+	JLT  ternaryVectorLoop; \
+	VPXOR   Y2, Y2, Y2; \
+	VPXOR   Y5, Y5, Y5; \
+	VMOVDQU (R13), Y3; \
+	VPXOR   (R11), Y3, Y3; \
+	VMOVDQU (R12), Y7; \
+	VPAND   Y7, Y3, Y3; \
+	NIBBLE_POPCNT(Y3, Y2); \
+	NIBBLE_POPCNT(Y7, Y5); \
+	VMOVDQU 32(R13), Y3; \
+	VPXOR   32(R11), Y3, Y3; \
+	VMOVDQU 32(R12), Y7; \
+	VPAND   Y7, Y3, Y3; \
+	NIBBLE_POPCNT(Y3, Y2); \
+	NIBBLE_POPCNT(Y7, Y5); \
+	VMOVDQU 64(R13), Y3; \
+	VPXOR   64(R11), Y3, Y3; \
+	VMOVDQU 64(R12), Y7; \
+	VPAND   Y7, Y3, Y3; \
+	NIBBLE_POPCNT(Y3, Y2); \
+	NIBBLE_POPCNT(Y7, Y5); \
+	VMOVDQU 96(R13), Y3; \
+	VPXOR   96(R11), Y3, Y3; \
+	VMOVDQU 96(R12), Y7; \
+	VPAND   Y7, Y3, Y3; \
+	NIBBLE_POPCNT(Y3, Y2); \
+	NIBBLE_POPCNT(Y7, Y5); \
+	FLUSH_BYTES(Y2, Y0); \
+	FLUSH_BYTES(Y5, Y6); \
+	ADDQ $128, R13; \
+	ADDQ $128, R11; \
+	ADDQ $128, R12; \
+	SUBQ $16, CX; \
+	JMP  ternaryBlockLoop; \
+ternaryVectorLoop: \
+	CMPQ CX, $4; \
+	JLT  ternaryWordLoop; \
+	VPXOR   Y2, Y2, Y2; \
+	VPXOR   Y5, Y5, Y5; \
+	VMOVDQU (R13), Y3; \
+	VPXOR   (R11), Y3, Y3; \
+	VMOVDQU (R12), Y7; \
+	VPAND   Y7, Y3, Y3; \
+	NIBBLE_POPCNT(Y3, Y2); \
+	NIBBLE_POPCNT(Y7, Y5); \
+	FLUSH_BYTES(Y2, Y0); \
+	FLUSH_BYTES(Y5, Y6); \
+	ADDQ $32, R13; \
+	ADDQ $32, R11; \
+	ADDQ $32, R12; \
+	SUBQ $4, CX; \
+	JMP  ternaryVectorLoop; \
+ternaryWordLoop: \
+	TESTQ CX, CX; \
+	JEQ   ternaryReduce; \
+	MOVQ    (R12), DI; \
+	POPCNTQ DI, DI; \
+	ADDQ    DI, R14; \
+	MOVQ    (R13), DI; \
+	XORQ    (R11), DI; \
+	ANDQ    (R12), DI; \
+	POPCNTQ DI, DI; \
+	ADDQ    DI, AX; \
+	ADDQ $8, R13; \
+	ADDQ $8, R11; \
+	ADDQ $8, R12; \
+	DECQ CX; \
+	JMP  ternaryWordLoop; \
+ternaryReduce: \
+	HSUM_AVX2(Y0, X0, DI); \
+	ADDQ DI, AX; \
+	HSUM_AVX2(Y6, X6, DI); \
+	ADDQ DI, R14; \
+	SHLQ $1, AX; \
+	SUBQ AX, R14; \
+	STORE R14, (DX); \
+	ADDQ $SIZE, DX; \
+	DECQ R10; \
+	JMP  ternarySignRowLoop; \
+ternaryNextValueRow: \
+	LEAQ (SI)(R9*8), SI; \
+	DECQ R8; \
+	JMP  ternaryValueRowLoop; \
+ternaryDone: \
+	VZEROUPPER; \
+	RET
+
+// func xorPopcntAVX2(aDataFirstElem, bDataFirstElem *uint64, n int) int
+TEXT ·xorPopcntAVX2(SB), NOSPLIT, $0-32
+	MOVQ aDataFirstElem+0(FP), SI
+	MOVQ bDataFirstElem+8(FP), DI
+	MOVQ n+16(FP), CX
+	NIBBLE_INIT
+	XOR_POPCNT_AVX2(SI, DI, CX, AX, R8)
+	MOVQ AX, ret+24(FP)
+	VZEROUPPER
+	RET
+
+// func dotAVX2(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int)
+TEXT ·dotAVX2(SB), NOSPLIT, $0-56
+	MOVQ leftDataFirstElem+0(FP), SI
+	MOVQ rightDataFirstElem+8(FP), DI
+	MOVQ leftRows+16(FP), R8
+	MOVQ rightRows+24(FP), BX
+	MOVQ cols+32(FP), R10
+	MOVQ stride+40(FP), R9
+	MOVQ resultsFirstElem+48(FP), DX
+	DOT_AVX2(MOVQ, 8)
+
+// func dotInt32AVX2(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int32)
+TEXT ·dotInt32AVX2(SB), NOSPLIT, $0-56
+	MOVQ leftDataFirstElem+0(FP), SI
+	MOVQ rightDataFirstElem+8(FP), DI
+	MOVQ leftRows+16(FP), R8
+	MOVQ rightRows+24(FP), BX
+	MOVQ cols+32(FP), R10
+	MOVQ stride+40(FP), R9
+	MOVQ resultsFirstElem+48(FP), DX
+	DOT_AVX2(MOVL, 4)
+
+// func dotInt16AVX2(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *int16)
+TEXT ·dotInt16AVX2(SB), NOSPLIT, $0-56
+	MOVQ leftDataFirstElem+0(FP), SI
+	MOVQ rightDataFirstElem+8(FP), DI
+	MOVQ leftRows+16(FP), R8
+	MOVQ rightRows+24(FP), BX
+	MOVQ cols+32(FP), R10
+	MOVQ stride+40(FP), R9
+	MOVQ resultsFirstElem+48(FP), DX
+	DOT_AVX2(MOVW, 2)
+
+// func dotTernaryAVX2(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int)
+TEXT ·dotTernaryAVX2(SB), NOSPLIT, $16-56
+	MOVQ valueDataFirstElem+0(FP), SI
+	MOVQ signDataFirstElem+8(FP), R11
+	MOVQ nonZeroDataFirstElem+16(FP), R12
+	MOVQ valueRows+24(FP), R8
+	MOVQ signRows+32(FP), BX
+	MOVQ stride+40(FP), R9
+	MOVQ resultsFirstElem+48(FP), DX
+	DOT_TERNARY_AVX2(MOVQ, 8)
+
+// func dotTernaryInt32AVX2(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int32)
+TEXT ·dotTernaryInt32AVX2(SB), NOSPLIT, $16-56
+	MOVQ valueDataFirstElem+0(FP), SI
+	MOVQ signDataFirstElem+8(FP), R11
+	MOVQ nonZeroDataFirstElem+16(FP), R12
+	MOVQ valueRows+24(FP), R8
+	MOVQ signRows+32(FP), BX
+	MOVQ stride+40(FP), R9
+	MOVQ resultsFirstElem+48(FP), DX
+	DOT_TERNARY_AVX2(MOVL, 4)
+
+// func dotTernaryInt16AVX2(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *int16)
+TEXT ·dotTernaryInt16AVX2(SB), NOSPLIT, $16-56
+	MOVQ valueDataFirstElem+0(FP), SI
+	MOVQ signDataFirstElem+8(FP), R11
+	MOVQ nonZeroDataFirstElem+16(FP), R12
+	MOVQ valueRows+24(FP), R8
+	MOVQ signRows+32(FP), BX
+	MOVQ stride+40(FP), R9
+	MOVQ resultsFirstElem+48(FP), DX
+	DOT_TERNARY_AVX2(MOVW, 2)
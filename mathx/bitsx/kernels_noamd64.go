@@ -5,6 +5,8 @@ package bitsx
 // amd64 以外では常に pure Go 実装へフォールバックする。
 var useAVX512 = false
 
+var useAVX2 = false
+
 func xorPopcntAVX512(a, b *uint64, n int) int {
 	panic("unreachable")
 }
@@ -16,3 +18,55 @@ func dotAVX512(leftData, rightData *uint64, leftRows, rightRows, cols, stride in
 func dotTernaryAVX512(valueData, signData, nonZeroData *uint64, valueRows, signRows, stride int, results *int) {
 	panic("unreachable")
 }
+
+func dotInt32AVX512(leftData, rightData *uint64, leftRows, rightRows, cols, stride int, results *int32) {
+	panic("unreachable")
+}
+
+func dotInt16AVX512(leftData, rightData *uint64, leftRows, rightRows, cols, stride int, results *int16) {
+	panic("unreachable")
+}
+
+func dotTernaryInt32AVX512(valueData, signData, nonZeroData *uint64, valueRows, signRows, stride int, results *int32) {
+	panic("unreachable")
+}
+
+func dotTernaryInt16AVX512(valueData, signData, nonZeroData *uint64, valueRows, signRows, stride int, results *int16) {
+	panic("unreachable")
+}
+
+func xorPopcntMaskedAVX512(a, b, mask *uint64, n int) int {
+	panic("unreachable")
+}
+
+func dotMaskedAVX512(leftData, rightData, maskData *uint64, leftRows, rightRows, maskOnes, stride int, results *int) {
+	panic("unreachable")
+}
+
+func xorPopcntAVX2(a, b *uint64, n int) int {
+	panic("unreachable")
+}
+
+func dotAVX2(leftData, rightData *uint64, leftRows, rightRows, cols, stride int, results *int) {
+	panic("unreachable")
+}
+
+func dotInt32AVX2(leftData, rightData *uint64, leftRows, rightRows, cols, stride int, results *int32) {
+	panic("unreachable")
+}
+
+func dotInt16AVX2(leftData, rightData *uint64, leftRows, rightRows, cols, stride int, results *int16) {
+	panic("unreachable")
+}
+
+func dotTernaryAVX2(valueData, signData, nonZeroData *uint64, valueRows, signRows, stride int, results *int) {
+	panic("unreachable")
+}
+
+func dotTernaryInt32AVX2(valueData, signData, nonZeroData *uint64, valueRows, signRows, stride int, results *int32) {
+	panic("unreachable")
+}
+
+func dotTernaryInt16AVX2(valueData, signData, nonZeroData *uint64, valueRows, signRows, stride int, results *int16) {
+	panic("unreachable")
+}
@@ -1,6 +1,7 @@
 package bitsx
 
 import (
+	"math"
 	"math/bits"
 	"math/rand/v2"
 	"testing"
@@ -48,6 +49,18 @@ func callDotTernaryAVX512(value, sign, nonZero *Matrix) []int {
 	return results
 }
 
+func callDotAVX2(left, right *Matrix) []int {
+	results := make([]int, left.rows*right.rows)
+	dotAVX2(&left.data[0], &right.data[0], left.rows, right.rows, left.cols, left.Stride(), &results[0])
+	return results
+}
+
+func callDotTernaryAVX2(value, sign, nonZero *Matrix) []int {
+	results := make([]int, value.rows*sign.rows)
+	dotTernaryAVX2(&value.data[0], &sign.data[0], &nonZero.data[0], value.rows, sign.rows, value.Stride(), &results[0])
+	return results
+}
+
 func TestXorPopcntGoExpectedValues(t *testing.T) {
 	tests := []struct {
 		name string
@@ -704,6 +717,370 @@ func FuzzDotTernaryAVX512VsGo(f *testing.F) {
 	})
 }
 
+func FuzzXorPopcntMaskedAVX512VsGo(f *testing.F) {
+	if !useAVX512 {
+		f.Skipf("AVX512命令は非対応の環境")
+	}
+
+	f.Add(uint8(0), uint64(0), uint64(0))                                    // 1ワード
+	f.Add(uint8(7), uint64(0x5555555555555555), uint64(0xAAAAAAAAAAAAAAAA))  // 8ワード
+	f.Add(uint8(11), uint64(0x2222222222222222), uint64(0xDDDDDDDDDDDDDDDD)) // 12ワード(1ループ+端数4)
+	f.Add(uint8(255), ^uint64(0), uint64(0))                                 // 256ワード
+
+	f.Fuzz(func(t *testing.T, words uint8, seed1, seed2 uint64) {
+		wordCount := int(words) + 1
+		rng := rand.New(rand.NewPCG(seed1, seed2))
+		a := make([]uint64, wordCount)
+		b := make([]uint64, wordCount)
+		mask := make([]uint64, wordCount)
+		for i := range wordCount {
+			a[i] = rng.Uint64()
+			b[i] = rng.Uint64()
+			mask[i] = rng.Uint64()
+		}
+
+		gotGo := xorPopcntMaskedGo(a, b, mask)
+		gotAVX512 := xorPopcntMaskedAVX512(&a[0], &b[0], &mask[0], wordCount)
+		if gotAVX512 != gotGo {
+			t.Fatalf("xorPopcntMaskedAVX512とxorPopcntMaskedGoの不一致: gotAVX512 = %d, gotGo = %d", gotAVX512, gotGo)
+		}
+	})
+}
+
+func FuzzDotMaskedAVX512VsGo(f *testing.F) {
+	if !useAVX512 {
+		f.Skipf("AVX512命令は非対応の環境")
+	}
+
+	f.Add(uint8(0), uint8(0), uint16(0), uint64(0), uint64(0))
+	f.Add(uint8(1), uint8(2), uint16(64), uint64(0x5555555555555555), uint64(0xAAAAAAAAAAAAAAAA))  // 65列/1ワード+1
+	f.Add(uint8(4), uint8(5), uint16(448), uint64(0x0F0F0F0F0F0F0F0F), uint64(0xF0F0F0F0F0F0F0F0)) // stride=8, 端数無し
+	f.Add(uint8(5), uint8(6), uint16(512), uint64(0x00FF00FF00FF00FF), uint64(0xFF00FF00FF00FF00)) // stride=9
+	f.Add(uint8(15), uint8(15), uint16(255), ^uint64(0), ^uint64(0))
+
+	f.Fuzz(func(t *testing.T, lRows, rRows uint8, cols uint16, seed1, seed2 uint64) {
+		leftRows := int(lRows%16 + 1)
+		rightRows := int(rRows%16 + 1)
+		columns := int(cols) + 1
+
+		rng := rand.New(rand.NewPCG(seed1, seed2))
+		left, err := NewRandMatrix(leftRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		right, err := NewRandMatrix(rightRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		mask, err := NewRandMatrix(1, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		maskOnes := mask.OnesCount()
+		stride := left.Stride()
+
+		gotGo := make([]int, leftRows*rightRows)
+		dotMaskedGo(left.data, right.data, mask.data, leftRows, rightRows, maskOnes, stride, gotGo)
+		gotAVX512 := make([]int, leftRows*rightRows)
+		dotMaskedAVX512(&left.data[0], &right.data[0], &mask.data[0], leftRows, rightRows, maskOnes, stride, &gotAVX512[0])
+
+		assertResults(t, "dotMaskedAVX512 vs dotMaskedGo", gotAVX512, gotGo)
+	})
+}
+
+func assertNarrowResults[T int32 | int16](t *testing.T, name string, got []T, want []int) {
+	t.Helper()
+	wide := make([]int, len(got))
+	for i, v := range got {
+		wide[i] = int(v)
+	}
+	assertResults(t, name, wide, want)
+}
+
+func FuzzDotNarrowAVX512VsGo(f *testing.F) {
+	if !useAVX512 {
+		f.Skipf("AVX512命令は非対応の環境")
+	}
+
+	f.Add(uint8(0), uint8(0), uint16(0), uint64(0), uint64(0))
+	f.Add(uint8(2), uint8(3), uint16(127), uint64(0x0123456789ABCDEF), uint64(0xFEDCBA9876543210))
+	f.Add(uint8(5), uint8(6), uint16(512), uint64(0x00FF00FF00FF00FF), uint64(0xFF00FF00FF00FF00))
+	f.Add(uint8(15), uint8(15), uint16(math.MaxInt16-1), ^uint64(0), ^uint64(0)) // int16の上限ちょうどの列数
+
+	f.Fuzz(func(t *testing.T, lRows, rRows uint8, cols uint16, seed1, seed2 uint64) {
+		leftRows := int(lRows%16 + 1)
+		rightRows := int(rRows%16 + 1)
+		// 0～65535を1～MaxInt16に変換
+		columns := int(cols)%math.MaxInt16 + 1
+
+		rng := rand.New(rand.NewPCG(seed1, seed2))
+		left, err := NewRandMatrix(leftRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		right, err := NewRandMatrix(rightRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		want := callDotGo(left, right)
+		stride := left.Stride()
+
+		got32 := make([]int32, len(want))
+		dotInt32AVX512(&left.data[0], &right.data[0], leftRows, rightRows, columns, stride, &got32[0])
+		assertNarrowResults(t, "dotInt32AVX512 vs dotGo", got32, want)
+
+		got16 := make([]int16, len(want))
+		dotInt16AVX512(&left.data[0], &right.data[0], leftRows, rightRows, columns, stride, &got16[0])
+		assertNarrowResults(t, "dotInt16AVX512 vs dotGo", got16, want)
+	})
+}
+
+func FuzzDotTernaryNarrowAVX512VsGo(f *testing.F) {
+	if !useAVX512 {
+		f.Skipf("AVX512命令は非対応の環境")
+	}
+
+	f.Add(uint8(0), uint8(0), uint16(0), uint64(0), uint64(0))
+	f.Add(uint8(3), uint8(8), uint16(128), uint64(0x6666666666666666), uint64(0x9999999999999999)) // ブロック境界(8フル+1端数)
+	f.Add(uint8(5), uint8(6), uint16(512), uint64(0x00FF00FF00FF00FF), uint64(0xFF00FF00FF00FF00))
+	f.Add(uint8(15), uint8(15), uint16(math.MaxInt16-1), ^uint64(0), ^uint64(0)) // int16の上限ちょうどの列数
+
+	f.Fuzz(func(t *testing.T, vRows, sRows uint8, cols uint16, seed1, seed2 uint64) {
+		valueRows := int(vRows%16 + 1)
+		signRows := int(sRows%16 + 1)
+		// 0～65535を1～MaxInt16に変換
+		columns := int(cols)%math.MaxInt16 + 1
+
+		rng := rand.New(rand.NewPCG(seed1, seed2))
+		value, err := NewRandMatrix(valueRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		sign, err := NewRandMatrix(signRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		nonZero, err := NewRandMatrix(signRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		want := callDotTernaryGo(value, sign, nonZero)
+		stride := value.Stride()
+
+		got32 := make([]int32, len(want))
+		dotTernaryInt32AVX512(&value.data[0], &sign.data[0], &nonZero.data[0], valueRows, signRows, stride, &got32[0])
+		assertNarrowResults(t, "dotTernaryInt32AVX512 vs dotTernaryGo", got32, want)
+
+		got16 := make([]int16, len(want))
+		dotTernaryInt16AVX512(&value.data[0], &sign.data[0], &nonZero.data[0], valueRows, signRows, stride, &got16[0])
+		assertNarrowResults(t, "dotTernaryInt16AVX512 vs dotTernaryGo", got16, want)
+	})
+}
+
+func FuzzXorPopcntAVX2VsGo(f *testing.F) {
+	if !useAVX2 {
+		f.Skipf("AVX2命令は非対応の環境")
+	}
+
+	seeds := []struct {
+		words        uint8
+		seed1, seed2 uint64
+	}{
+		{0, 0, 0}, // 1ワード
+		{2, 0x123456789ABCDEF0, 0x0FEDCBA987654321},   // 3ワード(端数のみ)
+		{3, 0x5555555555555555, 0xAAAAAAAAAAAAAAAA},   // 4ワード(1ベクトル)
+		{6, 0x0123456789ABCDEF, 0xFEDCBA9876543210},   // 7ワード(1ベクトル+端数3)
+		{15, 0, ^uint64(0)},                           // 16ワード(1ブロック)
+		{20, 0x2222222222222222, 0xDDDDDDDDDDDDDDDD},  // 21ワード(1ブロック+1ベクトル+端数1)
+		{127, 0xAAAAAAAAAAAAAAAA, 0x5555555555555555}, // 128ワード
+		{255, ^uint64(0), 0},                          // 256ワード(全ビット不一致)
+	}
+	for _, s := range seeds {
+		f.Add(s.words, s.seed1, s.seed2)
+	}
+
+	f.Fuzz(func(t *testing.T, words uint8, seed1, seed2 uint64) {
+		wordCount := int(words) + 1
+		rng := rand.New(rand.NewPCG(seed1, seed2))
+		a := make([]uint64, wordCount)
+		b := make([]uint64, wordCount)
+		for i := range wordCount {
+			a[i] = rng.Uint64()
+			b[i] = rng.Uint64()
+		}
+		// 各バイトの1の個数が最大になる入力で、バイト単位の和の桁あふれを検出する
+		if seed1 == ^seed2 {
+			for i := range wordCount {
+				a[i] = ^uint64(0)
+				b[i] = 0
+			}
+		}
+
+		gotGo := xorPopcntGo(a, b)
+		gotAVX2 := xorPopcntAVX2(&a[0], &b[0], wordCount)
+		if gotAVX2 != gotGo {
+			t.Fatalf("xorPopcntAVX2とxorPopcntGoの不一致: gotAVX2 = %d, gotGo = %d", gotAVX2, gotGo)
+		}
+	})
+}
+
+func FuzzDotAVX2VsGo(f *testing.F) {
+	if !useAVX2 {
+		f.Skipf("AVX2命令は非対応の環境")
+	}
+
+	seeds := []struct {
+		lRows, rRows uint8
+		cols         uint16
+		seed1, seed2 uint64
+	}{
+		{0, 0, 0, 0, 0}, // 極小ケース (1x1行列, 1列, 0シード)
+		{0, 15, 63, 0x123456789ABCDEF0, 0x0FEDCBA987654321},  // 不均衡ケース (1x16行列, 64列/1ワード)
+		{1, 2, 255, 0x5555555555555555, 0xAAAAAAAAAAAAAAAA},  // ベクトル境界(stride=4, 端数無し) (2x3行列, 256列)
+		{2, 3, 256, 0x0123456789ABCDEF, 0xFEDCBA9876543210},  // ベクトル+端数(stride=5) (3x4行列, 257列)
+		{3, 4, 1023, 0x3333333333333333, 0xCCCCCCCCCCCCCCCC}, // ブロック境界(stride=16, 端数無し) (4x5行列, 1024列)
+		{4, 5, 1471, 0x0F0F0F0F0F0F0F0F, 0xF0F0F0F0F0F0F0F0}, // ブロック+ベクトル+端数3(stride=23) (5x6行列, 1472列)
+		{15, 15, 2047, ^uint64(0), ^uint64(0)},               // 最大ケース (16x16行列, 2048列/32ワード, 最大値シード)
+	}
+	for _, s := range seeds {
+		f.Add(s.lRows, s.rRows, s.cols, s.seed1, s.seed2)
+	}
+
+	f.Fuzz(func(t *testing.T, lRows, rRows uint8, cols uint16, seed1, seed2 uint64) {
+		leftRows := int(lRows%16 + 1)
+		rightRows := int(rRows%16 + 1)
+		columns := int(cols) + 1
+
+		rng := rand.New(rand.NewPCG(seed1, seed2))
+		left, err := NewRandMatrix(leftRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		right, err := NewRandMatrix(rightRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		want := callDotGo(left, right)
+		assertResults(t, "dotAVX2 vs dotGo", callDotAVX2(left, right), want)
+
+		stride := left.Stride()
+		if columns <= math.MaxInt16 {
+			got32 := make([]int32, len(want))
+			dotInt32AVX2(&left.data[0], &right.data[0], leftRows, rightRows, columns, stride, &got32[0])
+			assertNarrowResults(t, "dotInt32AVX2 vs dotGo", got32, want)
+
+			got16 := make([]int16, len(want))
+			dotInt16AVX2(&left.data[0], &right.data[0], leftRows, rightRows, columns, stride, &got16[0])
+			assertNarrowResults(t, "dotInt16AVX2 vs dotGo", got16, want)
+		}
+	})
+}
+
+func FuzzDotTernaryAVX2VsGo(f *testing.F) {
+	if !useAVX2 {
+		f.Skipf("AVX2命令は非対応の環境")
+	}
+
+	seeds := []struct {
+		vRows, sRows uint8
+		cols         uint16
+		seed1, seed2 uint64
+	}{
+		{0, 0, 0, 0, 0}, // 極小ケース (1x1行列, 1列, 0シード)
+		{0, 15, 63, 0x123456789ABCDEF0, 0x0FEDCBA987654321},  // 不均衡ケース (1x16行列, 64列/1ワード)
+		{1, 2, 255, 0x5555555555555555, 0xAAAAAAAAAAAAAAAA},  // ベクトル境界(stride=4, 端数無し) (2x3行列, 256列)
+		{2, 3, 256, 0x0123456789ABCDEF, 0xFEDCBA9876543210},  // ベクトル+端数(stride=5) (3x4行列, 257列)
+		{3, 8, 1023, 0x6666666666666666, 0x9999999999999999}, // ブロック境界(stride=16, 端数無し) (4x9行列, 1024列)
+		{4, 5, 1471, 0x0F0F0F0F0F0F0F0F, 0xF0F0F0F0F0F0F0F0}, // ブロック+ベクトル+端数3(stride=23) (5x6行列, 1472列)
+		{15, 15, 2047, ^uint64(0), ^uint64(0)},               // 最大ケース (16x16行列, 2048列/32ワード, 最大値シード)
+	}
+	for _, s := range seeds {
+		f.Add(s.vRows, s.sRows, s.cols, s.seed1, s.seed2)
+	}
+
+	f.Fuzz(func(t *testing.T, vRows, sRows uint8, cols uint16, seed1, seed2 uint64) {
+		valueRows := int(vRows%16 + 1)
+		signRows := int(sRows%16 + 1)
+		columns := int(cols) + 1
+
+		rng := rand.New(rand.NewPCG(seed1, seed2))
+		value, err := NewRandMatrix(valueRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		sign, err := NewRandMatrix(signRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		nonZero, err := NewRandMatrix(signRows, columns, 0, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		want := callDotTernaryGo(value, sign, nonZero)
+		assertResults(t, "dotTernaryAVX2 vs dotTernaryGo", callDotTernaryAVX2(value, sign, nonZero), want)
+
+		stride := value.Stride()
+		if columns <= math.MaxInt16 {
+			got32 := make([]int32, len(want))
+			dotTernaryInt32AVX2(&value.data[0], &sign.data[0], &nonZero.data[0], valueRows, signRows, stride, &got32[0])
+			assertNarrowResults(t, "dotTernaryInt32AVX2 vs dotTernaryGo", got32, want)
+
+			got16 := make([]int16, len(want))
+			dotTernaryInt16AVX2(&value.data[0], &sign.data[0], &nonZero.data[0], valueRows, signRows, stride, &got16[0])
+			assertNarrowResults(t, "dotTernaryInt16AVX2 vs dotTernaryGo", got16, want)
+		}
+	})
+}
+
+// AVX512が使える環境でも、公開APIがAVX2の経路で同じ結果を返す事を確かめる。
+func TestDotAVX2Dispatch(t *testing.T) {
+	if !useAVX2 {
+		t.Skipf("AVX2命令は非対応の環境")
+	}
+
+	rng := rand.New(rand.NewPCG(7, 8))
+	left, err := NewRandMatrix(9, 1000, 0, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	right, err := NewRandMatrix(7, 1000, 0, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	nonZero, err := NewRandMatrix(7, 1000, 0, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	saved := useAVX512
+	useAVX512 = false
+	defer func() { useAVX512 = saved }()
+
+	dotResults, err := left.Dot(right)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	assertResults(t, "Dot", dotResults, callDotGo(left, right))
+
+	ternaryResults, err := left.DotTernary(right, nonZero)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	assertResults(t, "DotTernary", ternaryResults, callDotTernaryGo(left, right, nonZero))
+
+	distance, err := left.HammingDistance(left.Not())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if want := left.rows * left.cols; distance != want {
+		t.Fatalf("HammingDistance: got = %d, want %d", distance, want)
+	}
+}
+
 const (
 	benchXorPopcntCols = 8192
 
@@ -730,6 +1107,13 @@ func skipIfNoAVX512(b *testing.B) {
 	}
 }
 
+func skipIfNoAVX2(b *testing.B) {
+	b.Helper()
+	if !useAVX2 {
+		b.Skipf("AVX2命令は非対応の環境")
+	}
+}
+
 func BenchmarkXorPopcntGo(b *testing.B) {
 	rng := rand.New(rand.NewPCG(1, 2))
 	a := newBenchMatrix(b, 1, benchXorPopcntCols, rng)
@@ -755,6 +1139,17 @@ func BenchmarkXorPopcntAVX512(b *testing.B) {
 	}
 }
 
+func BenchmarkXorPopcntAVX2(b *testing.B) {
+	skipIfNoAVX2(b)
+	rng := rand.New(rand.NewPCG(1, 2))
+	a := newBenchMatrix(b, 1, benchXorPopcntCols, rng)
+	c := newBenchMatrix(b, 1, benchXorPopcntCols, rng)
+
+	for b.Loop() {
+		xorPopcntAVX2(&a.data[0], &c.data[0], len(a.data))
+	}
+}
+
 func BenchmarkDotGo(b *testing.B) {
 	rng := rand.New(rand.NewPCG(3, 4))
 	left := newBenchMatrix(b, benchDotRows, benchDotCols, rng)
@@ -782,6 +1177,18 @@ func BenchmarkDotAVX512(b *testing.B) {
 	}
 }
 
+func BenchmarkDotAVX2(b *testing.B) {
+	skipIfNoAVX2(b)
+	rng := rand.New(rand.NewPCG(3, 4))
+	left := newBenchMatrix(b, benchDotRows, benchDotCols, rng)
+	right := newBenchMatrix(b, benchDotRows, benchDotCols, rng)
+	results := make([]int, left.rows*right.rows)
+
+	for b.Loop() {
+		dotAVX2(&left.data[0], &right.data[0], left.rows, right.rows, left.cols, left.Stride(), &results[0])
+	}
+}
+
 func BenchmarkDotTernaryGo(b *testing.B) {
 	rng := rand.New(rand.NewPCG(5, 6))
 	value := newBenchMatrix(b, benchDotTernaryRows, benchDotTernaryCols, rng)
@@ -810,3 +1217,16 @@ func BenchmarkDotTernaryAVX512(b *testing.B) {
 		dotTernaryAVX512(&value.data[0], &sign.data[0], &nonZero.data[0], value.rows, sign.rows, value.Stride(), &results[0])
 	}
 }
+
+func BenchmarkDotTernaryAVX2(b *testing.B) {
+	skipIfNoAVX2(b)
+	rng := rand.New(rand.NewPCG(5, 6))
+	value := newBenchMatrix(b, benchDotTernaryRows, benchDotTernaryCols, rng)
+	sign := newBenchMatrix(b, benchDotTernaryRows, benchDotTernaryCols, rng)
+	nonZero := newBenchMatrix(b, benchDotTernaryRows, benchDotTernaryCols, rng)
+	results := make([]int, value.rows*sign.rows)
+
+	for b.Loop() {
+		dotTernaryAVX2(&value.data[0], &sign.data[0], &nonZero.data[0], value.rows, sign.rows, value.Stride(), &results[0])
+	}
+}
@@ -0,0 +1,337 @@
+package bitsx
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// CostFuncは、LocalSearchが最小化するコスト。Matrices.ETFCostをそのまま渡せる。
+type CostFunc func(ms Matrices) (float32, error)
+
+// Flipは、ms[Index]の(Row, Col)のビットの反転を表す。
+type Flip struct {
+	Index int
+	Row   int
+	Col   int
+}
+
+// FlipCandidateは、評価済みの候補。Costは、そのビットを反転した場合のコスト。
+type FlipCandidate struct {
+	Flip Flip
+	Cost float32
+}
+
+// SearchStateは、反復の開始時点の探索の状態。
+type SearchState struct {
+	Iter     int
+	Cost     float32
+	BestCost float32
+}
+
+// SearchStrategyは、LocalSearchの1反復で、どの候補へ移るかを決める。
+type SearchStrategy interface {
+	// Resetは、探索の開始時に呼ばれる。
+	Reset()
+
+	// NumCandidatesは、1反復で評価するランダムな候補の数。
+	NumCandidates() int
+
+	// Selectは、移る候補の添字を返す。移らない場合は-1を返す。
+	Select(state SearchState, candidates []FlipCandidate, rng *rand.Rand) int
+}
+
+// GreedySearchは、コストが下がる場合だけ移る山登り法。1反復で1つの候補を評価する。
+// LocalSearch{Strategy: &GreedySearch{}, MaxIters: iters} と ETFCost は、NewETFMatricesの反復と同じ探索になる。
+type GreedySearch struct{}
+
+func (g *GreedySearch) Reset() {}
+
+func (g *GreedySearch) NumCandidates() int {
+	return 1
+}
+
+func (g *GreedySearch) Select(state SearchState, candidates []FlipCandidate, rng *rand.Rand) int {
+	if candidates[0].Cost < state.Cost {
+		return 0
+	}
+	return -1
+}
+
+// TemperatureScheduleは、反復回数から焼きなまし法の温度を求める。
+type TemperatureSchedule func(iter int) float64
+
+// NewExponentialScheduleは、t0 * alpha^iter を返すスケジュールを作る。0 < alpha < 1 であるべき。
+func NewExponentialSchedule(t0, alpha float64) TemperatureSchedule {
+	return func(iter int) float64 {
+		return t0 * math.Pow(alpha, float64(iter))
+	}
+}
+
+// NewLinearScheduleは、t0から始まり、iters回で0になるスケジュールを作る。
+func NewLinearSchedule(t0 float64, iters int) TemperatureSchedule {
+	return func(iter int) float64 {
+		return t0 * max(0, 1-float64(iter)/float64(iters))
+	}
+}
+
+// SimulatedAnnealingは焼きなまし法。コストが下がる場合は必ず移り、
+// 上がる場合は exp(-(上がった量)/温度) の確率で移る。1反復で1つの候補を評価する。
+type SimulatedAnnealing struct {
+	// nilであってはならない
+	Schedule TemperatureSchedule
+}
+
+func (sa *SimulatedAnnealing) Reset() {}
+
+func (sa *SimulatedAnnealing) NumCandidates() int {
+	return 1
+}
+
+func (sa *SimulatedAnnealing) Select(state SearchState, candidates []FlipCandidate, rng *rand.Rand) int {
+	delta := float64(candidates[0].Cost - state.Cost)
+	if delta < 0 {
+		return 0
+	}
+
+	temperature := sa.Schedule(state.Iter)
+	if temperature <= 0 {
+		return -1
+	}
+
+	if rng.Float64() < math.Exp(-delta/temperature) {
+		return 0
+	}
+	return -1
+}
+
+// TabuSearchはタブー探索。1反復でCandidates個の候補を評価し、コストが上がる場合も含めて最良の候補へ移る。
+// 反転したビットは、Tenure(>= 0)回の反復の間は再び反転しない(タブー)。
+// ただし、これまでの最良のコストを下回る候補は、タブーであっても移る。
+// 探索中の状態を持つ為、同時に複数の探索で使ってはならない。
+type TabuSearch struct {
+	Tenure     int
+	Candidates int
+
+	expiries map[Flip]int
+}
+
+func (ts *TabuSearch) Reset() {
+	ts.expiries = map[Flip]int{}
+}
+
+func (ts *TabuSearch) NumCandidates() int {
+	return ts.Candidates
+}
+
+func (ts *TabuSearch) Select(state SearchState, candidates []FlipCandidate, rng *rand.Rand) int {
+	selected := -1
+	for i, c := range candidates {
+		tabu := ts.expiries[c.Flip] > state.Iter
+		if tabu && c.Cost >= state.BestCost {
+			continue
+		}
+		if selected < 0 || c.Cost < candidates[selected].Cost {
+			selected = i
+		}
+	}
+
+	if selected >= 0 {
+		ts.expiries[candidates[selected].Flip] = state.Iter + ts.Tenure + 1
+	}
+	return selected
+}
+
+// LocalSearchProgressは、Progressに渡す途中経過。
+type LocalSearchProgress struct {
+	Iter     int
+	Cost     float32
+	BestCost float32
+	Accepted int
+	Elapsed  time.Duration
+}
+
+// LocalSearchStopReasonは、探索を終えた理由。
+type LocalSearchStopReason int
+
+const (
+	StopMaxIters LocalSearchStopReason = iota
+	StopTimeLimit
+	StopPatience
+	StopProgress
+)
+
+type LocalSearchResult struct {
+	BestCost   float32
+	Iters      int
+	Accepted   int
+	StopReason LocalSearchStopReason
+}
+
+// LocalSearchは、1ビットの反転を近傍とする局所探索。
+// 各反復で、Strategy.NumCandidates()個のランダムな反転の候補のコストを求め、Strategyが選んだ候補へ移る。
+//
+// MaxIters・TimeLimitのどちらか一方は指定するべき。0は無制限を表す。
+// TimeLimitで終えた場合は反復回数が実行環境に依存する為、同じrngでも結果は再現しない。
+type LocalSearch struct {
+	Strategy SearchStrategy
+
+	MaxIters  int
+	TimeLimit time.Duration
+
+	// 最良のコストがPatience回の反復の間に下がらなければ終える。0なら無効
+	Patience int
+
+	// ProgressIntervalの反復ごとに呼ばれる。falseを返すと探索を終える。nilなら呼ばない
+	Progress         func(p LocalSearchProgress) bool
+	ProgressInterval int
+}
+
+// Runは、msのビットを反転してcostを最小化する。msは書き換えられ、終了時には見つけた最良の状態になる。
+func (ls *LocalSearch) Run(ms Matrices, cost CostFunc, rng *rand.Rand) (LocalSearchResult, error) {
+	if err := ls.validate(ms, cost, rng); err != nil {
+		return LocalSearchResult{}, err
+	}
+
+	current, err := cost(ms)
+	if err != nil {
+		return LocalSearchResult{}, err
+	}
+
+	best := cloneMatrices(ms)
+	result := LocalSearchResult{BestCost: current, StopReason: StopMaxIters}
+	lastImproved := 0
+	start := time.Now()
+
+	ls.Strategy.Reset()
+	candidates := make([]FlipCandidate, ls.Strategy.NumCandidates())
+
+	for iter := 0; ls.MaxIters == 0 || iter < ls.MaxIters; iter++ {
+		if ls.TimeLimit > 0 && time.Since(start) >= ls.TimeLimit {
+			result.StopReason = StopTimeLimit
+			break
+		}
+
+		if ls.Patience > 0 && iter-lastImproved >= ls.Patience {
+			result.StopReason = StopPatience
+			break
+		}
+
+		for i := range candidates {
+			idx := rng.IntN(len(ms))
+			flip := Flip{Index: idx, Row: rng.IntN(ms[idx].rows), Col: rng.IntN(ms[idx].cols)}
+			c, err := flipCost(ms, flip, cost)
+			if err != nil {
+				return LocalSearchResult{}, err
+			}
+			candidates[i] = FlipCandidate{Flip: flip, Cost: c}
+		}
+
+		state := SearchState{Iter: iter, Cost: current, BestCost: result.BestCost}
+		if selected := ls.Strategy.Select(state, candidates, rng); selected >= 0 {
+			c := candidates[selected]
+			if err := ms[c.Flip.Index].Toggle(c.Flip.Row, c.Flip.Col); err != nil {
+				return LocalSearchResult{}, err
+			}
+			current = c.Cost
+			result.Accepted++
+
+			if current < result.BestCost {
+				result.BestCost = current
+				lastImproved = iter + 1
+				copyMatrices(best, ms)
+			}
+		}
+		result.Iters = iter + 1
+
+		if ls.Progress != nil && result.Iters%ls.ProgressInterval == 0 {
+			p := LocalSearchProgress{
+				Iter:     result.Iters,
+				Cost:     current,
+				BestCost: result.BestCost,
+				Accepted: result.Accepted,
+				Elapsed:  time.Since(start),
+			}
+			if !ls.Progress(p) {
+				result.StopReason = StopProgress
+				break
+			}
+		}
+	}
+
+	copyMatrices(ms, best)
+	return result, nil
+}
+
+func (ls *LocalSearch) validate(ms Matrices, cost CostFunc, rng *rand.Rand) error {
+	if len(ms) == 0 {
+		return fmt.Errorf("len(ms) > 0 であるべき")
+	}
+
+	for i, m := range ms {
+		if err := m.validateDotAVX512Family(); err != nil {
+			return fmt.Errorf("ms[%d]: %w", i, err)
+		}
+	}
+
+	if cost == nil || rng == nil || ls.Strategy == nil {
+		return fmt.Errorf("cost・rng・Strategyはnilであってはならない")
+	}
+
+	if sa, ok := ls.Strategy.(*SimulatedAnnealing); ok && (sa == nil || sa.Schedule == nil) {
+		return fmt.Errorf("SimulatedAnnealing.Scheduleはnilであってはならない")
+	}
+
+	if ts, ok := ls.Strategy.(*TabuSearch); ok && ts != nil && ts.Tenure < 0 {
+		return fmt.Errorf("TabuSearch.Tenure >= 0 であるべき: Tenure = %d", ts.Tenure)
+	}
+
+	if ls.MaxIters < 0 || ls.TimeLimit < 0 || ls.Patience < 0 {
+		return fmt.Errorf("MaxIters・TimeLimit・Patienceは0以上であるべき: MaxIters = %d, TimeLimit = %v, Patience = %d",
+			ls.MaxIters, ls.TimeLimit, ls.Patience)
+	}
+
+	if ls.MaxIters == 0 && ls.TimeLimit == 0 {
+		return fmt.Errorf("MaxItersとTimeLimitのどちらか一方は指定するべき")
+	}
+
+	if ls.Progress != nil && ls.ProgressInterval <= 0 {
+		return fmt.Errorf("ProgressInterval > 0 であるべき: ProgressInterval = %d", ls.ProgressInterval)
+	}
+
+	if n := ls.Strategy.NumCandidates(); n <= 0 {
+		return fmt.Errorf("NumCandidates() > 0 であるべき: NumCandidates() = %d", n)
+	}
+	return nil
+}
+
+// flipを反転した場合のコストを求め、元に戻す。
+func flipCost(ms Matrices, flip Flip, cost CostFunc) (float32, error) {
+	m := ms[flip.Index]
+	if err := m.Toggle(flip.Row, flip.Col); err != nil {
+		return 0, err
+	}
+
+	c, costErr := cost(ms)
+	if err := m.Toggle(flip.Row, flip.Col); err != nil {
+		return 0, err
+	}
+	return c, costErr
+}
+
+func cloneMatrices(ms Matrices) Matrices {
+	cloned := make(Matrices, len(ms))
+	for i, m := range ms {
+		cloned[i] = m.Clone()
+	}
+	return cloned
+}
+
+// 形状が等しい前提で、srcの内容をdstへ書き込む。
+func copyMatrices(dst, src Matrices) {
+	for i, m := range src {
+		copy(dst[i].data, m.data)
+		dst[i].invalidateRanks()
+	}
+}
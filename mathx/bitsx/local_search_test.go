@@ -0,0 +1,223 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+// targetとのハミング距離の合計をコストとする。
+func newDistanceCost(t *testing.T, target bitsx.Matrices) bitsx.CostFunc {
+	t.Helper()
+	return func(ms bitsx.Matrices) (float32, error) {
+		sum := 0
+		for i, m := range ms {
+			d, err := m.HammingDistance(target[i])
+			if err != nil {
+				return 0, err
+			}
+			sum += d
+		}
+		return float32(sum), nil
+	}
+}
+
+func newRandMatrices(t *testing.T, rng *rand.Rand, n, rows, cols int) bitsx.Matrices {
+	t.Helper()
+	ms := make(bitsx.Matrices, n)
+	for i := range ms {
+		m, err := bitsx.NewRandMatrix(rows, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		ms[i] = m
+	}
+	return ms
+}
+
+func TestLocalSearchStrategies(t *testing.T) {
+	strategies := []struct {
+		name     string
+		strategy bitsx.SearchStrategy
+	}{
+		{"Greedy", &bitsx.GreedySearch{}},
+		{"SimulatedAnnealing", &bitsx.SimulatedAnnealing{Schedule: bitsx.NewExponentialSchedule(2, 0.99)}},
+		{"Tabu", &bitsx.TabuSearch{Tenure: 5, Candidates: 64}},
+	}
+
+	for _, s := range strategies {
+		t.Run("正常_"+s.name+"_最適解に到達する", func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(67, 68))
+			target := newRandMatrices(t, rng, 2, 2, 40)
+			ms := newRandMatrices(t, rng, 2, 2, 40)
+			cost := newDistanceCost(t, target)
+
+			ls := &bitsx.LocalSearch{Strategy: s.strategy, MaxIters: 5000}
+			result, err := ls.Run(ms, cost, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if result.BestCost != 0 {
+				t.Fatalf("最適解に到達しない: BestCost = %v", result.BestCost)
+			}
+
+			// msは最良の状態で返る
+			final, err := cost(ms)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if final != result.BestCost {
+				t.Fatalf("msが最良の状態ではない: got = %v, want = %v", final, result.BestCost)
+			}
+		})
+	}
+
+	t.Run("正常_同じシードなら同じ結果", func(t *testing.T) {
+		run := func() bitsx.Matrices {
+			rng := rand.New(rand.NewPCG(69, 70))
+			target := newRandMatrices(t, rng, 3, 1, 100)
+			ms := newRandMatrices(t, rng, 3, 1, 100)
+			ls := &bitsx.LocalSearch{
+				Strategy: &bitsx.SimulatedAnnealing{Schedule: bitsx.NewLinearSchedule(5, 300)},
+				MaxIters: 300,
+			}
+			if _, err := ls.Run(ms, newDistanceCost(t, target), rng); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			return ms
+		}
+		a, b := run(), run()
+		for i := range a {
+			if !a[i].Equal(b[i]) {
+				t.Fatalf("%d個目の行列が一致しない", i)
+			}
+		}
+	})
+
+	t.Run("正常_最良の状態へ戻す", func(t *testing.T) {
+		// 温度が高いと、ほぼ全ての候補へ移り、コストはランダムに上下する
+		rng := rand.New(rand.NewPCG(71, 72))
+		target := newRandMatrices(t, rng, 1, 1, 64)
+		ms := newRandMatrices(t, rng, 1, 1, 64)
+		cost := newDistanceCost(t, target)
+		ls := &bitsx.LocalSearch{
+			Strategy: &bitsx.SimulatedAnnealing{Schedule: bitsx.NewExponentialSchedule(1000, 1)},
+			MaxIters: 500,
+		}
+		result, err := ls.Run(ms, cost, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		final, err := cost(ms)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if final != result.BestCost {
+			t.Fatalf("msが最良の状態ではない: got = %v, want = %v", final, result.BestCost)
+		}
+	})
+}
+
+func TestLocalSearchGreedyMatchesNewETFMatrices(t *testing.T) {
+	const n, rows, cols, iters = 4, 2, 50, 300
+
+	want, err := bitsx.NewETFMatrices(n, rows, cols, iters, rand.New(rand.NewPCG(73, 74)))
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	// iters = 0 で、NewETFMatricesと同じ初期状態と乱数の状態を作る
+	rng := rand.New(rand.NewPCG(73, 74))
+	got, err := bitsx.NewETFMatrices(n, rows, cols, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	ls := &bitsx.LocalSearch{Strategy: &bitsx.GreedySearch{}, MaxIters: iters}
+	if _, err := ls.Run(got, bitsx.Matrices.ETFCost, rng); err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Fatalf("%d個目の行列が一致しない", i)
+		}
+	}
+}
+
+func TestLocalSearchStopping(t *testing.T) {
+	rng := rand.New(rand.NewPCG(75, 76))
+	target := newRandMatrices(t, rng, 1, 1, 64)
+	cost := newDistanceCost(t, target)
+
+	t.Run("正常_Patience", func(t *testing.T) {
+		cloned := bitsx.Matrices{target[0].Clone()}
+		// 既に最適なので、最良のコストは下がらない
+		ls := &bitsx.LocalSearch{Strategy: &bitsx.GreedySearch{}, MaxIters: 1000, Patience: 10}
+		result, err := ls.Run(cloned, cost, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if result.StopReason != bitsx.StopPatience || result.Iters != 10 {
+			t.Errorf("Patienceで終えていない: StopReason = %d, Iters = %d", result.StopReason, result.Iters)
+		}
+	})
+
+	t.Run("正常_Progress", func(t *testing.T) {
+		ms := newRandMatrices(t, rng, 1, 1, 64)
+		var calls []int
+		ls := &bitsx.LocalSearch{
+			Strategy:         &bitsx.GreedySearch{},
+			MaxIters:         1000,
+			ProgressInterval: 7,
+			Progress: func(p bitsx.LocalSearchProgress) bool {
+				calls = append(calls, p.Iter)
+				return len(calls) < 3
+			},
+		}
+		result, err := ls.Run(ms, cost, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if result.StopReason != bitsx.StopProgress || result.Iters != 21 {
+			t.Errorf("Progressで終えていない: StopReason = %d, Iters = %d", result.StopReason, result.Iters)
+		}
+		if len(calls) != 3 || calls[0] != 7 || calls[2] != 21 {
+			t.Errorf("Progressの呼び出しの不一致: %v", calls)
+		}
+	})
+
+	t.Run("正常_TimeLimit", func(t *testing.T) {
+		ms := newRandMatrices(t, rng, 1, 1, 64)
+		ls := &bitsx.LocalSearch{Strategy: &bitsx.GreedySearch{}, TimeLimit: 20 * time.Millisecond}
+		result, err := ls.Run(ms, cost, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if result.StopReason != bitsx.StopTimeLimit {
+			t.Errorf("TimeLimitで終えていない: StopReason = %d", result.StopReason)
+		}
+	})
+
+	t.Run("異常_引数が不正", func(t *testing.T) {
+		ms := newRandMatrices(t, rng, 1, 1, 64)
+		invalids := []*bitsx.LocalSearch{
+			{Strategy: &bitsx.GreedySearch{}},
+			{MaxIters: 10},
+			{Strategy: &bitsx.GreedySearch{}, MaxIters: -1},
+			{Strategy: &bitsx.TabuSearch{Tenure: 3}, MaxIters: 10},
+			{Strategy: &bitsx.TabuSearch{Tenure: -1, Candidates: 4}, MaxIters: 10},
+			{Strategy: &bitsx.SimulatedAnnealing{}, MaxIters: 10},
+			{Strategy: &bitsx.GreedySearch{}, MaxIters: 10, Progress: func(bitsx.LocalSearchProgress) bool { return true }},
+		}
+		for i, ls := range invalids {
+			if _, err := ls.Run(ms, cost, rng); err == nil {
+				t.Fatalf("%d: エラーを期待したが、nilが返された", i)
+			}
+		}
+		if _, err := (&bitsx.LocalSearch{Strategy: &bitsx.GreedySearch{}, MaxIters: 10}).Run(nil, cost, rng); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
@@ -2,17 +2,38 @@ package bitsx
 
 import (
 	"fmt"
+	"iter"
 	"math"
 	"math/rand/v2"
+	"slices"
+
+	"github.com/sw965/omw/parallel"
 )
 
 type Matrices []*Matrix
 
+// NewETFMatricesは、互いのハミング距離が大きく、かつ均等になるn個の行列を作る。
+// ランダムな行列から始め、1ビットを反転してETFCostが下がれば採用する事をiters回繰り返す。
 func NewETFMatrices(n, rows, cols int, iters int, rng *rand.Rand) (Matrices, error) {
+	return NewETFMatricesParallel(n, rows, cols, iters, 1, 1, rng)
+}
+
+// NewETFMatricesParallelは、1回の反復でcandidates個の反転の候補を、p個のgoroutineで評価する。
+// 候補のうちETFCostが最も下がるもの(同じなら先に引いたもの)を採用する。
+// 候補は直列にrngから引く為、結果はpに依らない。candidates = 1 ならNewETFMatricesと同じ。
+func NewETFMatricesParallel(n, rows, cols, iters, candidates, p int, rng *rand.Rand) (Matrices, error) {
 	if n < 2 {
 		return nil, fmt.Errorf("n >= 2 であるべき: n = %d", n)
 	}
 
+	if candidates < 1 {
+		return nil, fmt.Errorf("candidates >= 1 であるべき: candidates = %d", candidates)
+	}
+
+	if p < 1 {
+		return nil, fmt.Errorf("p >= 1 であるべき: p = %d", p)
+	}
+
 	ms := make(Matrices, n)
 	for i := range n {
 		m, err := NewRandMatrix(rows, cols, 0, rng)
@@ -22,34 +43,43 @@ func NewETFMatrices(n, rows, cols int, iters int, rng *rand.Rand) (Matrices, err
 		ms[i] = m
 	}
 
-	currentCost, err := ms.ETFCost()
+	state, err := newETFState(ms)
 	if err != nil {
 		return nil, err
 	}
 
+	flips := make([]Flip, candidates)
+	costs := make([]float32, candidates)
 	for range iters {
-		nIdx := rng.IntN(n)
-		rIdx := rng.IntN(rows)
-		cIdx := rng.IntN(cols)
-
-		err := ms[nIdx].Toggle(rIdx, cIdx)
-		if err != nil {
-			return nil, err
-		}
-
-		cost, err := ms.ETFCost()
-		if err != nil {
-			return nil, err
+		for i := range flips {
+			flips[i] = Flip{Index: rng.IntN(n), Row: rng.IntN(rows), Col: rng.IntN(cols)}
 		}
 
-		if cost < currentCost {
-			currentCost = cost
+		if p == 1 || candidates == 1 {
+			for i, flip := range flips {
+				costs[i] = state.flipCost(flip)
+			}
 		} else {
-			err := ms[nIdx].Toggle(rIdx, cIdx)
+			err := parallel.For(candidates, p, func(workerID, idx int) error {
+				costs[idx] = state.flipCost(flips[idx])
+				return nil
+			})
 			if err != nil {
 				return nil, err
 			}
 		}
+
+		best := -1
+		current := state.cost()
+		for i, cost := range costs {
+			if cost < current && (best < 0 || cost < costs[best]) {
+				best = i
+			}
+		}
+
+		if best >= 0 {
+			state.apply(flips[best])
+		}
 	}
 	return ms, nil
 }
@@ -151,42 +181,57 @@ func NewThermometerMatrices(n, rows, cols int) (Matrices, error) {
 	return ms, nil
 }
 
+func (ms Matrices) HStack() (*Matrix, error) {
+	return HStack(ms...)
+}
+
+func (ms Matrices) VStack() (*Matrix, error) {
+	return VStack(ms...)
+}
+
+// ETFCostは -(距離の合計) + (距離の分散) を返す。距離は全ての組のハミング距離。
+// 距離を最大化したいので、合計距離にはマイナスをつけて最小化問題にする。
+// また、距離の分散もコストに含める事で、均等な距離を保ちながら、距離を最大化する事が出来る。
 func (ms Matrices) ETFCost() (float32, error) {
 	n := len(ms)
 	if n < 2 {
 		return 0.0, fmt.Errorf("n >= 2 であるべき: n = %d", n)
 	}
 
-	distances := make([]float32, 0, n*n)
-	sum := float32(0.0)
+	distances := make([]int, 0, n*(n-1)/2)
 	for i := range len(ms) {
 		for j := i + 1; j < len(ms); j++ {
 			distance, err := ms[i].HammingDistance(ms[j])
 			if err != nil {
 				return 0.0, err
 			}
-			d := float32(distance)
-			distances = append(distances, d)
-			sum += d
+			distances = append(distances, distance)
 		}
 	}
+	return etfCost(slices.Values(distances)), nil
+}
+
+// 全ての組の距離から、ETFCostを求める。distancesは、(i, j) (i < j) の組を辞書順に返す。
+// 差分で更新するNewETFMatricesと、全ての組を数え直すETFCostが、同じ値を返すよう共通にする。
+// float32の丸めまで変えない為、float32で組の順に足し合わせる。
+func etfCost(distances iter.Seq[int]) float32 {
+	sum := float32(0.0)
+	dn := 0
+	for d := range distances {
+		sum += float32(d)
+		dn++
+	}
 
-	dn := len(distances)
 	dnf := float32(dn)
 	// 距離の平均
 	mean := sum / dnf
 
 	// 距離の分散
 	variance := float32(0.0)
-	for _, d := range distances {
-		deviation := d - mean
+	for d := range distances {
+		deviation := float32(d) - mean
 		variance += deviation * deviation
 	}
 	variance /= dnf
-
-	// コスト = -(合計距離) + (距離の分散)
-	// 距離を最大化したいので、合計距離にはマイナスをつけて最小化問題にする
-	// また、距離の分散もコストに含める事で、均等な距離を保ちながら、距離を最大化する事が出来る
-	cost := -sum + variance
-	return cost, nil
+	return -sum + variance
 }
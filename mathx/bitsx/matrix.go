@@ -13,6 +13,13 @@ type Matrix struct {
 	rows int
 	cols int
 	data []uint64
+
+	// BuildRankDirectoryで作るrank・selectの索引。ビットを変更するメソッドはnilに戻す
+	ranks *rankIndex
+
+	// RowViewでdataを共有する行列の間で共有する、dataへの書き込みの世代。
+	// 書き込む度に進め、作った時と世代が異なるranksは使わない
+	gen *uint64
 }
 
 func NewZerosMatrix(rows, cols int) (*Matrix, error) {
@@ -27,6 +34,7 @@ func NewZerosMatrix(rows, cols int) (*Matrix, error) {
 	m := &Matrix{
 		rows: rows,
 		cols: cols,
+		gen:  new(uint64),
 	}
 
 	stride := m.Stride()
@@ -146,6 +154,7 @@ func (m *Matrix) ApplyTailMask() {
 		idx := (r * stride) + (stride - 1)
 		m.data[idx] &= mask
 	}
+	m.invalidateRanks()
 }
 
 func (m *Matrix) ValidateSameShape(other *Matrix) error {
@@ -164,6 +173,8 @@ func (m *Matrix) Clone() *Matrix {
 		rows: m.rows,
 		cols: m.cols,
 		data: slices.Clone(m.data),
+		// 複製はdataを直接書き換えられる事が多い為、順位ディレクトリは引き継がない
+		gen: new(uint64),
 	}
 }
 
@@ -189,6 +200,7 @@ func (m *Matrix) SetWord(idx int, word uint64) error {
 		word &= m.TailMask()
 	}
 	m.data[idx] = word
+	m.invalidateRanks()
 	return nil
 }
 
@@ -220,6 +232,7 @@ func (m *Matrix) Set(r, c int) error {
 		return err
 	}
 	m.data[idx] |= (1 << shift)
+	m.invalidateRanks()
 	return nil
 }
 
@@ -229,6 +242,7 @@ func (m *Matrix) Clear(r, c int) error {
 		return err
 	}
 	m.data[idx] &^= (1 << shift)
+	m.invalidateRanks()
 	return nil
 }
 
@@ -238,6 +252,7 @@ func (m *Matrix) Toggle(r, c int) error {
 		return err
 	}
 	m.data[idx] ^= (1 << shift)
+	m.invalidateRanks()
 	return nil
 }
 
@@ -247,8 +262,8 @@ func (m *Matrix) And(other *Matrix) (*Matrix, error) {
 	}
 
 	c := m.Clone()
-	for i := range c.data {
-		c.data[i] &= other.data[i]
+	if err := c.AndInPlace(other); err != nil {
+		return nil, err
 	}
 	return c, nil
 }
@@ -259,8 +274,8 @@ func (m *Matrix) Xor(other *Matrix) (*Matrix, error) {
 	}
 
 	c := m.Clone()
-	for i := range c.data {
-		c.data[i] ^= other.data[i]
+	if err := c.XorInPlace(other); err != nil {
+		return nil, err
 	}
 	return c, nil
 }
@@ -292,48 +307,152 @@ func (m *Matrix) HammingDistance(other *Matrix) (int, error) {
 		return 0, nil
 	}
 
-	if useAVX512 {
-		return xorPopcntAVX512(&m.data[0], &other.data[0], len(m.data)), nil
+	return xorPopcnt(m.data, other.data), nil
+}
+
+// 端数ビットが0であることを前提とする。len(a) == len(b) > 0 であるべき。
+func xorPopcnt(a, b []uint64) int {
+	switch {
+	case useAVX512:
+		return xorPopcntAVX512(&a[0], &b[0], len(a))
+	case useAVX2:
+		return xorPopcntAVX2(&a[0], &b[0], len(a))
 	}
-	return xorPopcntGo(m.data, other.data), nil
+	return xorPopcntGo(a, b)
 }
 
 func (m *Matrix) Dot(other *Matrix) ([]int, error) {
-	resultsLen, err := validateDotAVX512Args(m, other)
+	return dot(m, other, dotAVX512, dotAVX2)
+}
+
+// DotIntoはDotの結果を、呼び出し側が確保したdstへ書き込む。len(dst) == m.Rows() * other.Rows() であるべき。
+func (m *Matrix) DotInto(dst []int, other *Matrix) error {
+	return dotInto(m, other, dst, dotAVX512, dotAVX2)
+}
+
+// DotInt32はDotの結果をint32で返す。Cols <= math.MaxInt32 であるべき。
+func (m *Matrix) DotInt32(other *Matrix) ([]int32, error) {
+	return dot(m, other, dotInt32AVX512, dotInt32AVX2)
+}
+
+func (m *Matrix) DotInt32Into(dst []int32, other *Matrix) error {
+	return dotInto(m, other, dst, dotInt32AVX512, dotInt32AVX2)
+}
+
+// DotInt16はDotの結果をint16で返す。Cols <= math.MaxInt16 であるべき。
+func (m *Matrix) DotInt16(other *Matrix) ([]int16, error) {
+	return dot(m, other, dotInt16AVX512, dotInt16AVX2)
+}
+
+func (m *Matrix) DotInt16Into(dst []int16, other *Matrix) error {
+	return dotInto(m, other, dst, dotInt16AVX512, dotInt16AVX2)
+}
+
+func (m *Matrix) DotTernary(sign, nonZero *Matrix) ([]int, error) {
+	return dotTernary(m, sign, nonZero, dotTernaryAVX512, dotTernaryAVX2)
+}
+
+// DotTernaryIntoはDotTernaryの結果を、呼び出し側が確保したdstへ書き込む。len(dst) == m.Rows() * sign.Rows() であるべき。
+func (m *Matrix) DotTernaryInto(dst []int, sign, nonZero *Matrix) error {
+	return dotTernaryInto(m, sign, nonZero, dst, dotTernaryAVX512, dotTernaryAVX2)
+}
+
+// DotTernaryInt32はDotTernaryの結果をint32で返す。Cols <= math.MaxInt32 であるべき。
+func (m *Matrix) DotTernaryInt32(sign, nonZero *Matrix) ([]int32, error) {
+	return dotTernary(m, sign, nonZero, dotTernaryInt32AVX512, dotTernaryInt32AVX2)
+}
+
+func (m *Matrix) DotTernaryInt32Into(dst []int32, sign, nonZero *Matrix) error {
+	return dotTernaryInto(m, sign, nonZero, dst, dotTernaryInt32AVX512, dotTernaryInt32AVX2)
+}
+
+// DotTernaryInt16はDotTernaryの結果をint16で返す。Cols <= math.MaxInt16 であるべき。
+func (m *Matrix) DotTernaryInt16(sign, nonZero *Matrix) ([]int16, error) {
+	return dotTernary(m, sign, nonZero, dotTernaryInt16AVX512, dotTernaryInt16AVX2)
+}
+
+func (m *Matrix) DotTernaryInt16Into(dst []int16, sign, nonZero *Matrix) error {
+	return dotTernaryInto(m, sign, nonZero, dst, dotTernaryInt16AVX512, dotTernaryInt16AVX2)
+}
+
+type dotKernelFunc[T dotResult] func(leftDataFirstElem, rightDataFirstElem *uint64, leftRows, rightRows, cols, stride int, resultsFirstElem *T)
+
+type dotTernaryKernelFunc[T dotResult] func(valueDataFirstElem, signDataFirstElem, nonZeroDataFirstElem *uint64, valueRows, signRows, stride int, resultsFirstElem *T)
+
+func dot[T dotResult](left, right *Matrix, kernelAVX512, kernelAVX2 dotKernelFunc[T]) ([]T, error) {
+	resultsLen, err := validateDotAVX512Args(left, right)
 	if err != nil {
 		return nil, err
 	}
 
-	leftRows := m.rows
-	rightRows := other.rows
-	stride := m.Stride()
-	results := make([]int, resultsLen)
-
-	if useAVX512 {
-		dotAVX512(&m.data[0], &other.data[0], leftRows, rightRows, m.cols, stride, &results[0])
-	} else {
-		dotGo(m.data, other.data, leftRows, rightRows, m.cols, stride, results)
+	results := make([]T, resultsLen)
+	if err := dotInto(left, right, results, kernelAVX512, kernelAVX2); err != nil {
+		return nil, err
 	}
 	return results, nil
 }
 
-func (m *Matrix) DotTernary(sign, nonZero *Matrix) ([]int, error) {
-	resultsLen, err := validateDotTernaryAVX512Args(m, sign, nonZero)
+func dotInto[T dotResult](left, right *Matrix, dst []T, kernelAVX512, kernelAVX2 dotKernelFunc[T]) error {
+	resultsLen, err := validateDotAVX512Args(left, right)
+	if err != nil {
+		return err
+	}
+
+	if err := validateDotResults(left.cols, resultsLen, dst); err != nil {
+		return err
+	}
+
+	leftRows := left.rows
+	rightRows := right.rows
+	stride := left.Stride()
+
+	switch {
+	case useAVX512:
+		kernelAVX512(&left.data[0], &right.data[0], leftRows, rightRows, left.cols, stride, &dst[0])
+	case useAVX2:
+		kernelAVX2(&left.data[0], &right.data[0], leftRows, rightRows, left.cols, stride, &dst[0])
+	default:
+		dotGo(left.data, right.data, leftRows, rightRows, left.cols, stride, dst)
+	}
+	return nil
+}
+
+func dotTernary[T dotResult](value, sign, nonZero *Matrix, kernelAVX512, kernelAVX2 dotTernaryKernelFunc[T]) ([]T, error) {
+	resultsLen, err := validateDotTernaryAVX512Args(value, sign, nonZero)
 	if err != nil {
 		return nil, err
 	}
 
-	valueRows := m.rows
+	results := make([]T, resultsLen)
+	if err := dotTernaryInto(value, sign, nonZero, results, kernelAVX512, kernelAVX2); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func dotTernaryInto[T dotResult](value, sign, nonZero *Matrix, dst []T, kernelAVX512, kernelAVX2 dotTernaryKernelFunc[T]) error {
+	resultsLen, err := validateDotTernaryAVX512Args(value, sign, nonZero)
+	if err != nil {
+		return err
+	}
+
+	if err := validateDotResults(value.cols, resultsLen, dst); err != nil {
+		return err
+	}
+
+	valueRows := value.rows
 	signRows := sign.rows
-	stride := m.Stride()
-	results := make([]int, resultsLen)
+	stride := value.Stride()
 
-	if useAVX512 {
-		dotTernaryAVX512(&m.data[0], &sign.data[0], &nonZero.data[0], valueRows, signRows, stride, &results[0])
-	} else {
-		dotTernaryGo(m.data, sign.data, nonZero.data, valueRows, signRows, stride, results)
+	switch {
+	case useAVX512:
+		kernelAVX512(&value.data[0], &sign.data[0], &nonZero.data[0], valueRows, signRows, stride, &dst[0])
+	case useAVX2:
+		kernelAVX2(&value.data[0], &sign.data[0], &nonZero.data[0], valueRows, signRows, stride, &dst[0])
+	default:
+		dotTernaryGo(value.data, sign.data, nonZero.data, valueRows, signRows, stride, dst)
 	}
-	return results, nil
+	return nil
 }
 
 func (m *Matrix) Transpose() (*Matrix, error) {
@@ -398,47 +517,25 @@ func (ctx MatrixWordContext) ScanBits(f func(i, col, colT int) error) error {
 	return nil
 }
 
+// ScanRowsWordは、rowIdxsの各行のワードごとにfを呼ぶ。rowIdxsがnilなら全ての行を順に走査する。
 func (m *Matrix) ScanRowsWord(rowIdxs []int, f func(ctx MatrixWordContext) error) error {
-	rows := m.rows
-	cols := m.cols
-	stride := m.Stride()
-
 	if rowIdxs == nil {
-		rowIdxs = make([]int, rows)
-		for i := range rows {
-			rowIdxs[i] = i
+		for ctx := range m.Words() {
+			if err := f(ctx); err != nil {
+				return err
+			}
 		}
+		return nil
 	}
 
+	stride := m.Stride()
 	for _, r := range rowIdxs {
-		if r < 0 || r >= rows {
-			return fmt.Errorf("0 <= row < %d であるべき: row = %d", rows, r)
+		if r < 0 || r >= m.rows {
+			return fmt.Errorf("0 <= row < %d であるべき: row = %d", m.rows, r)
 		}
 
-		rowWordOffset := r * stride
-		rowBitOffset := r * cols
 		for s := range stride {
-			colStart := s << 6
-			colEnd := colStart + 64
-
-			var isTail bool
-			if colEnd > cols {
-				colEnd = cols
-				isTail = true
-			}
-
-			err := f(MatrixWordContext{
-				matrixRows:  rows,
-				Row:         r,
-				WordIndex:   rowWordOffset + s,
-				ColStart:    colStart,
-				ColEnd:      colEnd,
-				GlobalStart: rowBitOffset + colStart,
-				GlobalEnd:   rowBitOffset + colEnd,
-				IsTail:      isTail,
-			})
-
-			if err != nil {
+			if err := f(m.wordContext(r, s, stride)); err != nil {
 				return err
 			}
 		}
@@ -446,6 +543,29 @@ func (m *Matrix) ScanRowsWord(rowIdxs []int, f func(ctx MatrixWordContext) error
 	return nil
 }
 
+func (m *Matrix) wordContext(r, s, stride int) MatrixWordContext {
+	colStart := s << 6
+	colEnd := colStart + 64
+
+	var isTail bool
+	if colEnd > m.cols {
+		colEnd = m.cols
+		isTail = true
+	}
+
+	rowBitOffset := r * m.cols
+	return MatrixWordContext{
+		matrixRows:  m.rows,
+		Row:         r,
+		WordIndex:   r*stride + s,
+		ColStart:    colStart,
+		ColEnd:      colEnd,
+		GlobalStart: rowBitOffset + colStart,
+		GlobalEnd:   rowBitOffset + colEnd,
+		IsTail:      isTail,
+	}
+}
+
 // 64x64ビットブロックの転置は、6段のビットブロック交換で行う。幅は32→16→8→4→2→1と半減する。
 // 各段が交換する2行は、行番号が1ビットだけ異なる。幅32/16/8はbit5/4/3、幅4/2/1はbit2/1/0。
 // 従って、前半3段は下位3ビットが同じ8行、後半3段は上位3ビットが同じ8行の中で閉じる。
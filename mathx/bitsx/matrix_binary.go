@@ -0,0 +1,230 @@
+package bitsx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/sw965/omw/mathx"
+)
+
+// Matrixのバイナリ形式(全てリトルエンディアン)
+//
+//	オフセット  サイズ        内容
+//	0           4             マジックナンバー "BXMT"
+//	4           4             バージョン (uint32, 1 または 2)
+//	8           8             Rows (uint64)
+//	16          8             Cols (uint64)
+//	24          8             フラグ (uint64, バージョン2のみ。ビット0: rank・selectのディレクトリを持つ)
+//	24 (32)     8*Rows*Stride data (uint64の列。各行はStrideワードで、端数ビットは0)
+//	末尾        4             ここまでの全バイトのCRC-32C (Castagnoli)
+//
+// フラグが全て0の場合はバージョン1で書き出す為、ディレクトリを持たない行列はバージョン1の読み手でも読める。
+// ディレクトリはgobと同様に、有無だけを記録し、読み込み時に作り直す。
+//
+// gobと違い、Go以外からも読める。またWriteTo・ReadFromは、dataを丸ごと複製せずに逐次読み書きする。
+const (
+	binaryMagic            = "BXMT"
+	binaryVersion          = 1
+	binaryVersionWithFlags = 2
+
+	binaryHeaderSize = 24
+	binaryFlagsSize  = 8
+
+	binaryFlagRankDirectory = 1 << 0
+
+	// WriteTo・ReadFromが一度に読み書きするワード数
+	binaryChunkWords = 4096
+)
+
+var binaryCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+func (m *Matrix) MarshalBinary() ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, binaryHeaderSize+binaryFlagsSize+8*len(m.data)+4))
+	if _, err := m.WriteTo(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (m *Matrix) UnmarshalBinary(b []byte) error {
+	r := bytes.NewReader(b)
+	decoded := &Matrix{}
+	if _, err := decoded.ReadFrom(r); err != nil {
+		return err
+	}
+
+	if r.Len() != 0 {
+		return fmt.Errorf("末尾に余分なデータがある: %d バイト", r.Len())
+	}
+
+	*m = *decoded
+	return nil
+}
+
+// WriteToはio.WriterToを実装する。
+func (m *Matrix) WriteTo(w io.Writer) (int64, error) {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return 0, fmt.Errorf("エンコードするMatrixが不正: %w", err)
+	}
+
+	crc := crc32.New(binaryCRCTable)
+	cw := &countingWriter{w: io.MultiWriter(w, crc)}
+
+	var flags uint64
+	if m.HasRankDirectory() {
+		flags |= binaryFlagRankDirectory
+	}
+
+	version := uint32(binaryVersion)
+	if flags != 0 {
+		version = binaryVersionWithFlags
+	}
+
+	header := make([]byte, 0, binaryHeaderSize+binaryFlagsSize)
+	header = append(header, binaryMagic...)
+	header = binary.LittleEndian.AppendUint32(header, version)
+	header = binary.LittleEndian.AppendUint64(header, uint64(m.rows))
+	header = binary.LittleEndian.AppendUint64(header, uint64(m.cols))
+	if version == binaryVersionWithFlags {
+		header = binary.LittleEndian.AppendUint64(header, flags)
+	}
+	if _, err := cw.Write(header); err != nil {
+		return cw.n, err
+	}
+
+	chunk := make([]byte, 0, 8*min(len(m.data), binaryChunkWords))
+	for start := 0; start < len(m.data); start += binaryChunkWords {
+		end := min(start+binaryChunkWords, len(m.data))
+		chunk = chunk[:0]
+		for _, word := range m.data[start:end] {
+			chunk = binary.LittleEndian.AppendUint64(chunk, word)
+		}
+		if _, err := cw.Write(chunk); err != nil {
+			return cw.n, err
+		}
+	}
+
+	// チェックサム自体はチェックサムの対象に含めない
+	sum := binary.LittleEndian.AppendUint32(nil, crc.Sum32())
+	n, err := w.Write(sum)
+	return cw.n + int64(n), err
+}
+
+// ReadFromはio.ReaderFromを実装する。rからMatrixを1つ読み、mを置き換える。
+// ヘッダとチェックサムが不正な場合や、GobDecodeと同じ検査を通らない場合はエラーを返し、mは変更しない。
+func (m *Matrix) ReadFrom(r io.Reader) (int64, error) {
+	crc := crc32.New(binaryCRCTable)
+	cr := &countingReader{r: io.TeeReader(r, crc)}
+
+	header := make([]byte, binaryHeaderSize)
+	if _, err := io.ReadFull(cr, header); err != nil {
+		return cr.n, fmt.Errorf("ヘッダの読み込みに失敗: %w", err)
+	}
+
+	if magic := string(header[0:4]); magic != binaryMagic {
+		return cr.n, fmt.Errorf("マジックナンバーが不正: %q: %q であるべき", magic, binaryMagic)
+	}
+
+	var flags uint64
+	switch version := binary.LittleEndian.Uint32(header[4:8]); version {
+	case binaryVersion:
+	case binaryVersionWithFlags:
+		b := make([]byte, binaryFlagsSize)
+		if _, err := io.ReadFull(cr, b); err != nil {
+			return cr.n, fmt.Errorf("フラグの読み込みに失敗: %w", err)
+		}
+		flags = binary.LittleEndian.Uint64(b)
+		if unknown := flags &^ binaryFlagRankDirectory; unknown != 0 {
+			return cr.n, fmt.Errorf("未対応のフラグ: %#x", unknown)
+		}
+	default:
+		return cr.n, fmt.Errorf("未対応のバージョン: %d: %d または %d であるべき", version, binaryVersion, binaryVersionWithFlags)
+	}
+
+	rows64 := binary.LittleEndian.Uint64(header[8:16])
+	cols64 := binary.LittleEndian.Uint64(header[16:24])
+	if rows64 > math.MaxInt || cols64 > math.MaxInt {
+		return cr.n, fmt.Errorf("RowsまたはColsが大きすぎる: Rows = %d, Cols = %d", rows64, cols64)
+	}
+
+	decoded := &Matrix{rows: int(rows64), cols: int(cols64), gen: new(uint64)}
+	// dataを読む前に、validateDotAVX512Familyと同じ形状の検査を行う
+	wantDataLen, err := decoded.validateShape()
+	if err != nil {
+		return cr.n, fmt.Errorf("デコードされたMatrixが不正: %w", err)
+	}
+
+	if _, ok := mathx.MulOverflowChecked(wantDataLen, 8); !ok {
+		return cr.n, fmt.Errorf("RowsとColsが大きすぎる: Rows = %d, Cols = %d", decoded.rows, decoded.cols)
+	}
+
+	// 不正なヘッダで巨大な領域を一度に確保しないよう、読めた分だけ伸ばす
+	decoded.data = make([]uint64, 0, min(wantDataLen, binaryChunkWords))
+	chunk := make([]byte, 8*min(wantDataLen, binaryChunkWords))
+	for len(decoded.data) < wantDataLen {
+		words := min(wantDataLen-len(decoded.data), binaryChunkWords)
+		if _, err := io.ReadFull(cr, chunk[:8*words]); err != nil {
+			return cr.n, fmt.Errorf("dataの読み込みに失敗: %w", err)
+		}
+		for i := range words {
+			decoded.data = append(decoded.data, binary.LittleEndian.Uint64(chunk[8*i:]))
+		}
+	}
+
+	if err := readChecksum(cr, crc); err != nil {
+		return cr.n, err
+	}
+
+	if err := decoded.validateDotAVX512Family(); err != nil {
+		return cr.n, fmt.Errorf("デコードされたMatrixが不正: %w", err)
+	}
+
+	decoded.ApplyTailMask()
+	if flags&binaryFlagRankDirectory != 0 {
+		decoded.buildRanks()
+	}
+	*m = *decoded
+	return cr.n, nil
+}
+
+func readChecksum(r io.Reader, crc hash.Hash32) error {
+	// チェックサム自体はチェックサムの対象に含めない為、先に求めておく
+	want := crc.Sum32()
+
+	sum := make([]byte, 4)
+	if _, err := io.ReadFull(r, sum); err != nil {
+		return fmt.Errorf("チェックサムの読み込みに失敗: %w", err)
+	}
+
+	if got := binary.LittleEndian.Uint32(sum); got != want {
+		return fmt.Errorf("チェックサムの不一致: got = %#08x, want = %#08x", got, want)
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
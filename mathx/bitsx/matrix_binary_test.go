@@ -0,0 +1,119 @@
+package bitsx_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestMatrixBinaryRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(37, 38))
+
+	t.Run("正常_MarshalBinaryとUnmarshalBinaryの往復", func(t *testing.T) {
+		for _, shape := range []struct{ rows, cols int }{{1, 1}, {3, 130}, {100, 4096}} {
+			want, err := bitsx.NewRandMatrix(shape.rows, shape.cols, 0, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			b, err := want.MarshalBinary()
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+
+			wantLen := 24 + 8*shape.rows*want.Stride() + 4
+			if len(b) != wantLen {
+				t.Fatalf("(%d, %d): バイト数の不一致: got = %d, want = %d", shape.rows, shape.cols, len(b), wantLen)
+			}
+			if string(b[:4]) != "BXMT" || binary.LittleEndian.Uint32(b[4:]) != 1 ||
+				binary.LittleEndian.Uint64(b[8:]) != uint64(shape.rows) || binary.LittleEndian.Uint64(b[16:]) != uint64(shape.cols) {
+				t.Fatalf("(%d, %d): ヘッダが仕様と一致しない: % x", shape.rows, shape.cols, b[:24])
+			}
+
+			var got bitsx.Matrix
+			if err := got.UnmarshalBinary(b); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if !want.Equal(&got) {
+				t.Fatalf("(%d, %d): 往復で内容が変化した", shape.rows, shape.cols)
+			}
+		}
+	})
+
+	t.Run("正常_WriteToとReadFromで複数の行列を連続して読み書きできる", func(t *testing.T) {
+		a, b := newRandMatrixPair(t, 5, 70, rng)
+		var buf bytes.Buffer
+		na, err := a.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		nb, err := b.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if int(na+nb) != buf.Len() {
+			t.Fatalf("書き込んだバイト数の不一致: got = %d, want = %d", na+nb, buf.Len())
+		}
+
+		var gotA, gotB bitsx.Matrix
+		n, err := gotA.ReadFrom(&buf)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if n != na {
+			t.Fatalf("読み込んだバイト数の不一致: got = %d, want = %d", n, na)
+		}
+		if _, err := gotB.ReadFrom(&buf); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !gotA.Equal(a) || !gotB.Equal(b) {
+			t.Fatalf("往復で内容が変化した")
+		}
+	})
+}
+
+func TestMatrixUnmarshalBinaryInvalid(t *testing.T) {
+	rng := rand.New(rand.NewPCG(39, 40))
+	m, err := bitsx.NewRandMatrix(3, 100, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	valid, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	corrupt := func(f func(b []byte) []byte) []byte {
+		return f(bytes.Clone(valid))
+	}
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"空", nil},
+		{"マジックナンバーが不正", corrupt(func(b []byte) []byte { b[0] = 'X'; return b })},
+		{"バージョンが不正", corrupt(func(b []byte) []byte { b[4] = 3; return b })},
+		{"Rowsが0", corrupt(func(b []byte) []byte { clear(b[8:16]); return b })},
+		{"Rowsが巨大", corrupt(func(b []byte) []byte { binary.LittleEndian.PutUint64(b[8:], 1<<62); return b })},
+		{"Colsが負相当", corrupt(func(b []byte) []byte { binary.LittleEndian.PutUint64(b[16:], ^uint64(0)); return b })},
+		{"dataが破損", corrupt(func(b []byte) []byte { b[30] ^= 1; return b })},
+		{"チェックサムが破損", corrupt(func(b []byte) []byte { b[len(b)-1] ^= 1; return b })},
+		{"途中で切れている", valid[:len(valid)-10]},
+		{"末尾に余分なデータ", append(bytes.Clone(valid), 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run("異常_"+tt.name, func(t *testing.T) {
+			got := m.Clone()
+			if err := got.UnmarshalBinary(tt.b); err == nil {
+				t.Fatalf("エラーを期待したが、nilが返された")
+			}
+			if !got.Equal(m) {
+				t.Fatalf("エラー時にレシーバが変更された")
+			}
+		})
+	}
+}
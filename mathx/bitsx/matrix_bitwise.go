@@ -0,0 +1,164 @@
+package bitsx
+
+// 〇〇Into(dst, other) は、mと〇〇した結果をdstへ書き込む。
+// 〇〇InPlace(other) は、mと〇〇した結果をmへ書き込む。
+// どちらも新たなメモリを確保しない。dstはm・otherと同じ行列であってもよい。
+
+func (m *Matrix) validateIntoArgs(dst, other *Matrix) error {
+	if err := m.ValidateSameShape(other); err != nil {
+		return err
+	}
+	return m.ValidateSameShape(dst)
+}
+
+func (m *Matrix) AndInto(dst, other *Matrix) error {
+	if err := m.validateIntoArgs(dst, other); err != nil {
+		return err
+	}
+
+	for i := range dst.data {
+		dst.data[i] = m.data[i] & other.data[i]
+	}
+	dst.invalidateRanks()
+	return nil
+}
+
+func (m *Matrix) AndInPlace(other *Matrix) error {
+	return m.AndInto(m, other)
+}
+
+func (m *Matrix) Or(other *Matrix) (*Matrix, error) {
+	if err := m.ValidateSameShape(other); err != nil {
+		return nil, err
+	}
+
+	c := m.Clone()
+	if err := c.OrInPlace(other); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (m *Matrix) OrInto(dst, other *Matrix) error {
+	if err := m.validateIntoArgs(dst, other); err != nil {
+		return err
+	}
+
+	for i := range dst.data {
+		dst.data[i] = m.data[i] | other.data[i]
+	}
+	dst.invalidateRanks()
+	return nil
+}
+
+func (m *Matrix) OrInPlace(other *Matrix) error {
+	return m.OrInto(m, other)
+}
+
+func (m *Matrix) XorInto(dst, other *Matrix) error {
+	if err := m.validateIntoArgs(dst, other); err != nil {
+		return err
+	}
+
+	for i := range dst.data {
+		dst.data[i] = m.data[i] ^ other.data[i]
+	}
+	dst.invalidateRanks()
+	return nil
+}
+
+func (m *Matrix) XorInPlace(other *Matrix) error {
+	return m.XorInto(m, other)
+}
+
+// AndNotは m & ^other を返す。
+func (m *Matrix) AndNot(other *Matrix) (*Matrix, error) {
+	if err := m.ValidateSameShape(other); err != nil {
+		return nil, err
+	}
+
+	c := m.Clone()
+	if err := c.AndNotInPlace(other); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (m *Matrix) AndNotInto(dst, other *Matrix) error {
+	if err := m.validateIntoArgs(dst, other); err != nil {
+		return err
+	}
+
+	// mの端数ビットが0なので、結果の端数ビットも0のまま
+	for i := range dst.data {
+		dst.data[i] = m.data[i] &^ other.data[i]
+	}
+	dst.invalidateRanks()
+	return nil
+}
+
+func (m *Matrix) AndNotInPlace(other *Matrix) error {
+	return m.AndNotInto(m, other)
+}
+
+// Xnorは ^(m ^ other) を返す。端数ビットは0に保たれる。
+func (m *Matrix) Xnor(other *Matrix) (*Matrix, error) {
+	if err := m.ValidateSameShape(other); err != nil {
+		return nil, err
+	}
+
+	c := m.Clone()
+	if err := c.XnorInPlace(other); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (m *Matrix) XnorInto(dst, other *Matrix) error {
+	if err := m.validateIntoArgs(dst, other); err != nil {
+		return err
+	}
+
+	for i := range dst.data {
+		dst.data[i] = ^(m.data[i] ^ other.data[i])
+	}
+	dst.invalidateRanks()
+
+	// 反転により端数ビットが1になる為、0に戻す
+	dst.ApplyTailMask()
+	return nil
+}
+
+func (m *Matrix) XnorInPlace(other *Matrix) error {
+	return m.XnorInto(m, other)
+}
+
+// Notは全ビットを反転した行列を返す。端数ビットは0に保たれる。
+func (m *Matrix) Not() *Matrix {
+	c := m.Clone()
+	c.NotInPlace()
+	return c
+}
+
+func (m *Matrix) NotInto(dst *Matrix) error {
+	if err := m.ValidateSameShape(dst); err != nil {
+		return err
+	}
+
+	for i := range dst.data {
+		dst.data[i] = ^m.data[i]
+	}
+	dst.invalidateRanks()
+
+	// 反転により端数ビットが1になる為、0に戻す
+	dst.ApplyTailMask()
+	return nil
+}
+
+func (m *Matrix) NotInPlace() {
+	for i := range m.data {
+		m.data[i] = ^m.data[i]
+	}
+	m.invalidateRanks()
+	m.ApplyTailMask()
+}
@@ -0,0 +1,178 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func newRandMatrixPair(t *testing.T, rows, cols int, rng *rand.Rand) (*bitsx.Matrix, *bitsx.Matrix) {
+	t.Helper()
+	a, err := bitsx.NewRandMatrix(rows, cols, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	b, err := bitsx.NewRandMatrix(rows, cols, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	return a, b
+}
+
+// 素朴(愚直)な実装で、全ての(r,c)について op(a[r][c], b[r][c]) と一致するかを確かめる
+func assertBitwise(t *testing.T, name string, got, a, b *bitsx.Matrix, op func(x, y uint64) uint64) {
+	t.Helper()
+	for r := range a.Rows() {
+		for c := range a.Cols() {
+			x, err := a.Bit(r, c)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			y, err := b.Bit(r, c)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			g, err := got.Bit(r, c)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if want := op(x, y) & 1; g != want {
+				t.Fatalf("%s: (%d, %d)の値の不一致: got = %d, want = %d", name, r, c, g, want)
+			}
+		}
+	}
+
+	// 各行の最後のワードについて、端数ビットが0に保たれているか
+	stride := got.Stride()
+	for r := range got.Rows() {
+		word, err := got.Word(r*stride + stride - 1)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if word&^got.TailMask() != 0 {
+			t.Fatalf("%s: %d行目の端数ビットが0に保たれていない: word = %#x", name, r, word)
+		}
+	}
+}
+
+func TestMatrixBitwise(t *testing.T) {
+	rng := rand.New(rand.NewPCG(13, 14))
+
+	type binaryOp struct {
+		name    string
+		want    func(x, y uint64) uint64
+		alloc   func(a, b *bitsx.Matrix) (*bitsx.Matrix, error)
+		into    func(a, dst, b *bitsx.Matrix) error
+		inPlace func(a, b *bitsx.Matrix) error
+	}
+
+	ops := []binaryOp{
+		{"And", func(x, y uint64) uint64 { return x & y }, (*bitsx.Matrix).And, (*bitsx.Matrix).AndInto, (*bitsx.Matrix).AndInPlace},
+		{"Or", func(x, y uint64) uint64 { return x | y }, (*bitsx.Matrix).Or, (*bitsx.Matrix).OrInto, (*bitsx.Matrix).OrInPlace},
+		{"Xor", func(x, y uint64) uint64 { return x ^ y }, (*bitsx.Matrix).Xor, (*bitsx.Matrix).XorInto, (*bitsx.Matrix).XorInPlace},
+		{"AndNot", func(x, y uint64) uint64 { return x &^ y }, (*bitsx.Matrix).AndNot, (*bitsx.Matrix).AndNotInto, (*bitsx.Matrix).AndNotInPlace},
+		{"Xnor", func(x, y uint64) uint64 { return ^(x ^ y) }, (*bitsx.Matrix).Xnor, (*bitsx.Matrix).XnorInto, (*bitsx.Matrix).XnorInPlace},
+	}
+
+	for _, op := range ops {
+		for _, cols := range []int{1, 63, 64, 65, 130} {
+			a, b := newRandMatrixPair(t, 3, cols, rng)
+			orig := a.Clone()
+
+			got, err := op.alloc(a, b)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			assertBitwise(t, op.name, got, a, b, op.want)
+			if !a.Equal(orig) {
+				t.Fatalf("%s: レシーバが変更された", op.name)
+			}
+
+			dst, err := bitsx.NewOnesMatrix(3, cols)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if err := op.into(a, dst, b); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			assertBitwise(t, op.name+"Into", dst, a, b, op.want)
+
+			if err := op.inPlace(a, b); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			assertBitwise(t, op.name+"InPlace", a, orig, b, op.want)
+		}
+
+		t.Run("異常_"+op.name+"の形状不一致", func(t *testing.T) {
+			a, err := bitsx.NewZerosMatrix(2, 10)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			b, err := bitsx.NewZerosMatrix(2, 11)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if _, err := op.alloc(a, b); err == nil {
+				t.Fatalf("エラーを期待したが、nilが返された")
+			}
+			// 結果の行列(128KiB)を確保する前に、形状を検査する
+			big, err := bitsx.NewZerosMatrix(1024, 1024)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			_, _ = op.alloc(big, b)
+			runtime.ReadMemStats(&after)
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated >= 64*1024 {
+				t.Errorf("形状不一致で結果の行列が確保された: %d バイト", allocated)
+			}
+			if err := op.into(a, b, a); err == nil {
+				t.Fatalf("エラーを期待したが、nilが返された")
+			}
+			if err := op.inPlace(a, b); err == nil {
+				t.Fatalf("エラーを期待したが、nilが返された")
+			}
+		})
+	}
+}
+
+func TestMatrixNot(t *testing.T) {
+	rng := rand.New(rand.NewPCG(15, 16))
+	not := func(x, _ uint64) uint64 { return ^x }
+
+	for _, cols := range []int{1, 63, 64, 65, 130} {
+		a, _ := newRandMatrixPair(t, 3, cols, rng)
+		orig := a.Clone()
+
+		assertBitwise(t, "Not", a.Not(), a, a, not)
+
+		dst, err := bitsx.NewZerosMatrix(3, cols)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := a.NotInto(dst); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		assertBitwise(t, "NotInto", dst, a, a, not)
+
+		a.NotInPlace()
+		assertBitwise(t, "NotInPlace", a, orig, orig, not)
+	}
+
+	t.Run("異常_NotIntoの形状不一致", func(t *testing.T) {
+		a, err := bitsx.NewZerosMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		b, err := bitsx.NewZerosMatrix(3, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := a.NotInto(b); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package bitsx
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// BoolMulは、論理和・論理積の半環での積 C[i][j] = OR_k (A[i][k] AND B[k][j]) を返す。
+// Bを転置し、Aのi行目とBᵀのj行目のANDが0でないかをワード単位で調べる。
+func BoolMul(a, b *Matrix) (*Matrix, error) {
+	if a.cols != b.rows {
+		return nil, fmt.Errorf("a.Cols = b.Rows であるべき: a.Cols = %d, b.Rows = %d", a.cols, b.rows)
+	}
+
+	if err := a.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	bt, err := b.Transpose()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewZerosMatrix(a.rows, b.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := a.Stride()
+	cStride := c.Stride()
+	for i := range a.rows {
+		aRow := a.data[i*stride : (i+1)*stride]
+		cRow := c.data[i*cStride : (i+1)*cStride]
+		for j := range bt.rows {
+			if intersects(aRow, bt.data[j*stride:(j+1)*stride]) {
+				cRow[j/64] |= 1 << uint(j%64)
+			}
+		}
+	}
+	return c, nil
+}
+
+// 2つの行に共通する1があるか。見つかった時点で打ち切る。
+func intersects(a, b []uint64) bool {
+	for i, word := range a {
+		if word&b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Matrix) validateSquare() error {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return err
+	}
+
+	if m.rows != m.cols {
+		return fmt.Errorf("正方行列であるべき: (%d x %d)", m.rows, m.cols)
+	}
+	return nil
+}
+
+// TransitiveClosureは、mを隣接行列(i行j列が1なら辺 i→j)とみなし、推移閉包を返す。
+// 長さ1以上の経路で i から j へ到達できれば、i行j列が1になる。自己ループの無い頂点の対角は0のまま。
+// Warshall法を行単位のORで行い、O(n^3/64)で求める。
+func (m *Matrix) TransitiveClosure() (*Matrix, error) {
+	if err := m.validateSquare(); err != nil {
+		return nil, err
+	}
+
+	closure := m.Clone()
+	n := m.rows
+	stride := m.Stride()
+	for k := range n {
+		kRow := closure.data[k*stride : (k+1)*stride]
+		word, bit := k/64, uint64(1)<<uint(k%64)
+		for i := range n {
+			iRow := closure.data[i*stride : (i+1)*stride]
+			if iRow[word]&bit == 0 {
+				continue
+			}
+			// i→k かつ k→j なら i→j
+			for s, w := range kRow {
+				iRow[s] |= w
+			}
+		}
+	}
+	return closure, nil
+}
+
+// Reachableは、mを隣接行列とみなし、fromから長さ0以上の経路で到達できる頂点を返す。fromは必ず含まれる。
+// 幅優先探索の各段で、前線の頂点の行をまとめてORする。
+func (m *Matrix) Reachable(from int) (*Vector, error) {
+	if err := m.validateSquare(); err != nil {
+		return nil, err
+	}
+
+	if from < 0 || from >= m.rows {
+		return nil, fmt.Errorf("0 <= from < %d であるべき: from = %d", m.rows, from)
+	}
+
+	visited, err := NewVectorFromIndices(m.rows, []int{from})
+	if err != nil {
+		return nil, err
+	}
+	frontier := visited.Clone()
+
+	stride := m.Stride()
+	next := make([]uint64, stride)
+	for {
+		clear(next)
+		for i, word := range frontier.data {
+			for ; word != 0; word = ClearLowest(word) {
+				v := i*64 + bits.TrailingZeros64(word)
+				for s, w := range m.data[v*stride : (v+1)*stride] {
+					next[s] |= w
+				}
+			}
+		}
+
+		// 未訪問の頂点だけを次の前線にする。どちらも索引を作っていない為、dataを直接書き換えてよい
+		found := false
+		for s, w := range next {
+			w &^= visited.data[s]
+			frontier.data[s] = w
+			visited.data[s] |= w
+			found = found || w != 0
+		}
+		if !found {
+			return visited, nil
+		}
+	}
+}
+
+// IsReachableは、fromからtoへ長さ0以上の経路で到達できるかを返す。
+func (m *Matrix) IsReachable(from, to int) (bool, error) {
+	if to < 0 || to >= m.cols {
+		return false, fmt.Errorf("0 <= to < %d であるべき: to = %d", m.cols, to)
+	}
+
+	reachable, err := m.Reachable(from)
+	if err != nil {
+		return false, err
+	}
+
+	bit, err := reachable.Bit(to)
+	return bit == 1, err
+}
@@ -0,0 +1,152 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func naiveBoolMul(t *testing.T, a, b *bitsx.Matrix) *bitsx.Matrix {
+	t.Helper()
+	c, err := bitsx.NewZerosMatrix(a.Rows(), b.Cols())
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	for i := range a.Rows() {
+		for j := range b.Cols() {
+			for k := range a.Cols() {
+				if mustBit(t, a, i, k) == 1 && mustBit(t, b, k, j) == 1 {
+					if err := c.Set(i, j); err != nil {
+						t.Fatalf("予期せぬエラー: %v", err)
+					}
+					break
+				}
+			}
+		}
+	}
+	return c
+}
+
+func TestBoolMul(t *testing.T) {
+	rng := rand.New(rand.NewPCG(105, 106))
+	shapes := []struct{ n, k, m int }{{1, 1, 1}, {3, 70, 5}, {10, 64, 130}, {65, 20, 65}}
+	for _, s := range shapes {
+		for _, density := range []int{-4, -2, 0} {
+			a, err := bitsx.NewRandMatrix(s.n, s.k, density, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			b, err := bitsx.NewRandMatrix(s.k, s.m, density, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			got, err := bitsx.BoolMul(a, b)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if want := naiveBoolMul(t, a, b); !got.Equal(want) {
+				t.Fatalf("%v, density = %d: 値の不一致: got = %v, want = %v", s, density, got, want)
+			}
+		}
+	}
+
+	t.Run("異常_形状の不一致", func(t *testing.T) {
+		a, err := bitsx.NewZerosMatrix(2, 3)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := bitsx.BoolMul(a, a); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestMatrixTransitiveClosure(t *testing.T) {
+	rng := rand.New(rand.NewPCG(107, 108))
+	for _, n := range []int{1, 5, 64, 100} {
+		adj, err := bitsx.NewRandMatrix(n, n, -5, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		got, err := adj.TransitiveClosure()
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		// A + A^2 + ... + A^n をBoolMulで求める
+		want := adj.Clone()
+		power := adj.Clone()
+		for range n {
+			power, err = bitsx.BoolMul(power, adj)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if err := want.OrInPlace(power); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+		}
+		if !got.Equal(want) {
+			t.Fatalf("n = %d: 値の不一致", n)
+		}
+
+		for from := range n {
+			reachable, err := adj.Reachable(from)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			for to := range n {
+				// 長さ0の経路を含む
+				want := to == from || mustBit(t, got, from, to) == 1
+				if bit, _ := reachable.Bit(to); (bit == 1) != want {
+					t.Fatalf("n = %d: Reachable(%d)の%d番目の不一致", n, from, to)
+				}
+				if ok, err := adj.IsReachable(from, to); err != nil || ok != want {
+					t.Fatalf("n = %d: IsReachable(%d, %d)の不一致: got = %v, err = %v", n, from, to, ok, err)
+				}
+			}
+		}
+	}
+
+	t.Run("正常_経路", func(t *testing.T) {
+		// 0→1→2, 3→3
+		adj, err := bitsx.ParseMatrix("[0100 0010 0000 0001]")
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err := adj.TransitiveClosure()
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if want := "[0110 0010 0000 0001]"; got.String() != want {
+			t.Errorf("値の不一致: got = %v, want = %s", got, want)
+		}
+		if ok, _ := adj.IsReachable(2, 0); ok {
+			t.Errorf("到達できないはずの頂点へ到達した")
+		}
+	})
+
+	t.Run("異常_正方行列でない・範囲外", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(2, 3)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.TransitiveClosure(); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.Reachable(0); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		sq, err := bitsx.NewZerosMatrix(3, 3)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := sq.IsReachable(0, 3); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := sq.Reachable(-1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
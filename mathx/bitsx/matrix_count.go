@@ -0,0 +1,68 @@
+package bitsx
+
+import (
+	"math/bits"
+)
+
+// 列ごとの計数に使う縦方向のカウンタのビット数。(1<<colCountPlanes)-1 行ごとにintの計数へ移す
+const colCountPlanes = 8
+
+// RowOnesCountは、各行の1の個数を返す。
+func (m *Matrix) RowOnesCount() []int {
+	stride := m.Stride()
+	counts := make([]int, m.rows)
+	for r := range m.rows {
+		count := 0
+		for _, word := range m.data[r*stride : (r+1)*stride] {
+			count += bits.OnesCount64(word)
+		}
+		counts[r] = count
+	}
+	return counts
+}
+
+// ColOnesCountは、各列の1の個数を返す。
+//
+// 列ごとにBitで数えると、1行あたり64回の分岐が必要になる。
+// 代わりに、ワードごとにcolCountPlanes枚のビットプレーンを縦方向のカウンタとして持ち、
+// 各行のワードを桁上げ保存加算で足し込む。planes[k]のbビット目が、列bの計数の2^kの桁を表す。
+// 1行あたりの演算は、ワードごとに平均2回程度の論理演算で済む。
+func (m *Matrix) ColOnesCount() []int {
+	stride := m.Stride()
+	counts := make([]int, m.cols)
+	planes := make([]uint64, stride*colCountPlanes)
+
+	flush := func() {
+		for s := range stride {
+			for k, plane := range planes[s*colCountPlanes : (s+1)*colCountPlanes] {
+				for plane != 0 {
+					b := bits.TrailingZeros64(plane)
+					counts[s*64+b] += 1 << uint(k)
+					plane = ClearLowest(plane)
+				}
+			}
+		}
+		clear(planes)
+	}
+
+	const flushRows = (1 << colCountPlanes) - 1
+	for r := range m.rows {
+		row := m.data[r*stride : (r+1)*stride]
+		for s, word := range row {
+			wordPlanes := planes[s*colCountPlanes : (s+1)*colCountPlanes]
+			carry := word
+			for k := 0; carry != 0; k++ {
+				next := wordPlanes[k] & carry
+				wordPlanes[k] ^= carry
+				carry = next
+			}
+		}
+
+		// カウンタがあふれる前にintの計数へ移す
+		if (r+1)%flushRows == 0 {
+			flush()
+		}
+	}
+	flush()
+	return counts
+}
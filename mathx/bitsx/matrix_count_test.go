@@ -0,0 +1,53 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestMatrixRowAndColOnesCount(t *testing.T) {
+	rng := rand.New(rand.NewPCG(45, 46))
+
+	// 600行は、縦方向のカウンタを途中で2回移す(255行ごと)
+	shapes := []struct{ rows, cols int }{{1, 1}, {3, 70}, {255, 64}, {600, 130}}
+	for _, s := range shapes {
+		for _, k := range []int{-2, 0, 2} {
+			m, err := bitsx.NewRandMatrix(s.rows, s.cols, k, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+
+			wantRows := make([]int, s.rows)
+			wantCols := make([]int, s.cols)
+			for r := range s.rows {
+				for c := range s.cols {
+					bit := int(mustBit(t, m, r, c))
+					wantRows[r] += bit
+					wantCols[c] += bit
+				}
+			}
+
+			if got := m.RowOnesCount(); !slices.Equal(got, wantRows) {
+				t.Fatalf("(%d, %d): RowOnesCountの不一致: got = %v, want = %v", s.rows, s.cols, got, wantRows)
+			}
+			if got := m.ColOnesCount(); !slices.Equal(got, wantCols) {
+				t.Fatalf("(%d, %d): ColOnesCountの不一致: got = %v, want = %v", s.rows, s.cols, got, wantCols)
+			}
+		}
+	}
+
+	t.Run("正常_全て1なら各列は行数", func(t *testing.T) {
+		m, err := bitsx.NewOnesMatrix(1000, 100)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		for c, got := range m.ColOnesCount() {
+			if got != 1000 {
+				t.Fatalf("%d列目の値の不一致: got = %d, want = 1000", c, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package bitsx
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// これを超える行数・列数は、先頭と末尾の半分ずつだけを表示し、間を省略する
+	formatMaxRows = 32
+	formatMaxCols = 128
+
+	// 格子では . が0を表す為、省略には区別できる … を使う
+	elisionMark = "…"
+)
+
+// Stringは %v と同じ簡潔な形式を返す。例: 3行4列なら [0110 1001 0000]
+func (m *Matrix) String() string {
+	var sb strings.Builder
+	m.writeCompact(&sb)
+	return sb.String()
+}
+
+// Formatはfmt.Formatterを実装する。
+//
+//	%v, %s : [0110 1001 0000] のように、各行を0/1で表し空白で区切る
+//	%+v    : 1を#、0を.とし、1行ずつ改行で区切る格子
+//
+// どちらも大きな行列は行・列の中央を … で省略する。省略の無い出力はParseMatrixで読み戻せる。
+func (m *Matrix) Format(f fmt.State, verb rune) {
+	var sb strings.Builder
+	switch verb {
+	case 'v', 's':
+		if verb == 'v' && f.Flag('+') {
+			m.writeGrid(&sb)
+		} else {
+			m.writeCompact(&sb)
+		}
+	default:
+		fmt.Fprintf(f, "%%!%c(*bitsx.Matrix=%s)", verb, m.String())
+		return
+	}
+	f.Write([]byte(sb.String()))
+}
+
+func (m *Matrix) writeCompact(sb *strings.Builder) {
+	sb.WriteByte('[')
+	for i, r := range elidedIndices(m.rows, formatMaxRows) {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		if r < 0 {
+			sb.WriteString(elisionMark)
+			continue
+		}
+		m.writeRow(sb, r, '0', '1')
+	}
+	sb.WriteByte(']')
+}
+
+func (m *Matrix) writeGrid(sb *strings.Builder) {
+	for i, r := range elidedIndices(m.rows, formatMaxRows) {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		if r < 0 {
+			sb.WriteString(elisionMark)
+			continue
+		}
+		m.writeRow(sb, r, '.', '#')
+	}
+}
+
+func (m *Matrix) writeRow(sb *strings.Builder, r int, zero, one byte) {
+	row := m.data[r*m.Stride() : (r+1)*m.Stride()]
+	for _, c := range elidedIndices(m.cols, formatMaxCols) {
+		if c < 0 {
+			sb.WriteString(elisionMark)
+			continue
+		}
+		if (row[c/64]>>uint(c%64))&1 == 1 {
+			sb.WriteByte(one)
+		} else {
+			sb.WriteByte(zero)
+		}
+	}
+}
+
+// 0..n-1 を返す。n > limit の場合は、先頭と末尾のlimit/2個ずつを返し、省略箇所を-1で表す。
+func elidedIndices(n, limit int) []int {
+	if n <= limit {
+		idxs := make([]int, n)
+		for i := range n {
+			idxs[i] = i
+		}
+		return idxs
+	}
+
+	half := limit / 2
+	idxs := make([]int, 0, limit+1)
+	for i := range half {
+		idxs = append(idxs, i)
+	}
+	idxs = append(idxs, -1)
+	for i := n - half; i < n; i++ {
+		idxs = append(idxs, i)
+	}
+	return idxs
+}
+
+// ParseMatrixは、Formatが出力する形式の文字列から行列を作る。
+//   - [0110 1001] のように [] で囲まれていれば、空白区切りの各要素を1行とみなす
+//   - そうでなければ、空行を除く各行を1行とみなす(前後の空白は無視する)
+//
+// 1は # または 1、0は . または 0 で表す。全ての行の長さは等しくなければならない。
+// 省略(…)を含む文字列は読めない。
+func ParseMatrix(s string) (*Matrix, error) {
+	var lines []string
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+		lines = strings.Fields(trimmed[1 : len(trimmed)-1])
+	} else {
+		for line := range strings.Lines(trimmed) {
+			line = strings.TrimSpace(line)
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	return parseRows(lines)
+}
+
+// 1行ずつの文字列から行列を作る。
+func parseRows(lines []string) (*Matrix, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("行が1つも無い")
+	}
+
+	cols := len(lines[0])
+	m, err := NewZerosMatrix(len(lines), cols)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := m.Stride()
+	for r, line := range lines {
+		if len(line) != cols {
+			return nil, fmt.Errorf("%d行目の長さが不一致: %d: %d であるべき", r, len(line), cols)
+		}
+
+		row := m.data[r*stride : (r+1)*stride]
+		for c := range len(line) {
+			switch line[c] {
+			case '#', '1':
+				row[c/64] |= 1 << uint(c%64)
+			case '.', '0':
+			default:
+				return nil, fmt.Errorf("%d行%d列目の文字が不正: %q: # 1 . 0 のいずれかであるべき", r, c, line[c])
+			}
+		}
+	}
+	return m, nil
+}
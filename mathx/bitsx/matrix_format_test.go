@@ -0,0 +1,97 @@
+package bitsx_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestMatrixFormat(t *testing.T) {
+	m, err := bitsx.ParseMatrix(`
+		#..#
+		.##.
+		....
+	`)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	t.Run("正常_%v", func(t *testing.T) {
+		want := "[1001 0110 0000]"
+		if got := fmt.Sprintf("%v", m); got != want {
+			t.Errorf("値の不一致: got = %q, want = %q", got, want)
+		}
+		if got := m.String(); got != want {
+			t.Errorf("Stringの値の不一致: got = %q, want = %q", got, want)
+		}
+	})
+
+	t.Run("正常_%+v", func(t *testing.T) {
+		want := "#..#\n.##.\n...."
+		if got := fmt.Sprintf("%+v", m); got != want {
+			t.Errorf("値の不一致: got = %q, want = %q", got, want)
+		}
+	})
+
+	t.Run("正常_大きな行列は省略される", func(t *testing.T) {
+		big, err := bitsx.NewOnesMatrix(100, 1000)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		lines := strings.Split(fmt.Sprintf("%+v", big), "\n")
+		// 先頭16行 + 省略 + 末尾16行
+		if len(lines) != 33 || lines[16] != "…" {
+			t.Fatalf("行の省略が不正: 行数 = %d", len(lines))
+		}
+		// 先頭64列 + 省略 + 末尾64列
+		if want := strings.Repeat("#", 64) + "…" + strings.Repeat("#", 64); lines[0] != want {
+			t.Fatalf("列の省略が不正: got = %q", lines[0])
+		}
+	})
+}
+
+func TestParseMatrix(t *testing.T) {
+	t.Run("正常_FormatとParseMatrixの往復", func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(35, 36))
+		for _, shape := range []struct{ rows, cols int }{{1, 1}, {3, 70}, {32, 128}} {
+			want, err := bitsx.NewRandMatrix(shape.rows, shape.cols, 0, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			for _, format := range []string{"%v", "%+v"} {
+				got, err := bitsx.ParseMatrix(fmt.Sprintf(format, want))
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				if !got.Equal(want) {
+					t.Fatalf("(%d, %d) %s: 往復で内容が変化した", shape.rows, shape.cols, format)
+				}
+			}
+		}
+	})
+
+	t.Run("正常_0と1の格子", func(t *testing.T) {
+		got, err := bitsx.ParseMatrix("10\n01")
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want, err := bitsx.ParseMatrix("[#. .#]")
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("値の不一致: got = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("異常_不正な入力", func(t *testing.T) {
+		for _, s := range []string{"", "[]", "#.\n#", "#x", "#…#"} {
+			if _, err := bitsx.ParseMatrix(s); err == nil {
+				t.Fatalf("%q: エラーを期待したが、nilが返された", s)
+			}
+		}
+	})
+}
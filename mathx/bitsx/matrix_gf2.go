@@ -0,0 +1,281 @@
+package bitsx
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// GF(2)上の線形代数。各行はワード列なので、行の加算はワード単位のXORで済む。
+
+const (
+	// GF2MulでMethod of Four Russiansを使う左の行数の下限。
+	// 8行ずつの組合せ表(256行)を作る費用を、左の行数で償却できる大きさ
+	gf2M4RMinRows = 128
+
+	// Method of Four Russiansで1度にまとめる右の行数
+	gf2M4RBits = 8
+)
+
+// GF2Mulは、GF(2)上の行列積 A·B を返す。Cのi行目は、A[i][k] = 1 となる全てのkについてBのk行目のXOR。
+// Aの行数が多い場合は、Method of Four Russiansを使う。
+func GF2Mul(a, b *Matrix) (*Matrix, error) {
+	if a.cols != b.rows {
+		return nil, fmt.Errorf("a.Cols = b.Rows であるべき: a.Cols = %d, b.Rows = %d", a.cols, b.rows)
+	}
+
+	if err := a.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	if err := b.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	c, err := NewZerosMatrix(a.rows, b.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.rows >= gf2M4RMinRows {
+		gf2MulM4R(a, b, c)
+	} else {
+		gf2MulNaive(a, b, c)
+	}
+	return c, nil
+}
+
+func gf2MulNaive(a, b, c *Matrix) {
+	aStride := a.Stride()
+	bStride := b.Stride()
+	for i := range a.rows {
+		cRow := c.data[i*bStride : (i+1)*bStride]
+		for s, word := range a.data[i*aStride : (i+1)*aStride] {
+			for ; word != 0; word = ClearLowest(word) {
+				k := s*64 + bits.TrailingZeros64(word)
+				xorRow(cRow, b.data[k*bStride:(k+1)*bStride])
+			}
+		}
+	}
+}
+
+// Bの行をgf2M4RBits行ずつ組にし、その組の全ての線形結合(256通り)の表を作る。
+// Aの各行は、組に対応する8ビットで表を1回引くだけで、8行分のXORを済ませられる。
+func gf2MulM4R(a, b, c *Matrix) {
+	aStride := a.Stride()
+	bStride := b.Stride()
+	table := make([]uint64, (1<<gf2M4RBits)*bStride)
+
+	for k0 := 0; k0 < a.cols; k0 += gf2M4RBits {
+		width := min(gf2M4RBits, a.cols-k0)
+
+		// table[x] = XOR_{jビット目が1} B[k0+j]。最上位のビットを除いた表に1行を足して作る
+		for x := 1; x < 1<<width; x++ {
+			high := bits.Len(uint(x)) - 1
+			entry := table[x*bStride : (x+1)*bStride]
+			copy(entry, table[(x&^(1<<high))*bStride:])
+			xorRow(entry, b.data[(k0+high)*bStride:(k0+high+1)*bStride])
+		}
+
+		// 組の8ビットは、ワード境界を跨がない(k0は8の倍数)
+		w, shift := k0/64, uint(k0%64)
+		mask := uint64(1)<<uint(width) - 1
+		for i := range a.rows {
+			x := int((a.data[i*aStride+w] >> shift) & mask)
+			if x != 0 {
+				xorRow(c.data[i*bStride:(i+1)*bStride], table[x*bStride:(x+1)*bStride])
+			}
+		}
+	}
+}
+
+func xorRow(dst, src []uint64) {
+	for i, word := range src {
+		dst[i] ^= word
+	}
+}
+
+// GF2RowEchelonは、GF(2)上の既約行階段形と、各行の先頭の1(ピボット)の列を返す。
+// 結果の先頭len(pivots)行がピボットを持ち、残りの行は0。
+func (m *Matrix) GF2RowEchelon() (*Matrix, []int, error) {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return nil, nil, err
+	}
+
+	r := m.Clone()
+	pivots := r.gf2Eliminate(m.cols)
+	return r, pivots, nil
+}
+
+// 先頭のcols列について、その場でガウス・ジョルダン消去を行い、ピボットの列を返す。
+func (m *Matrix) gf2Eliminate(cols int) []int {
+	stride := m.Stride()
+	row := func(i int) []uint64 {
+		return m.data[i*stride : (i+1)*stride]
+	}
+
+	var pivots []int
+	for c := 0; c < cols && len(pivots) < m.rows; c++ {
+		p := len(pivots)
+		w, bit := c/64, uint64(1)<<uint(c%64)
+
+		found := -1
+		for i := p; i < m.rows; i++ {
+			if m.data[i*stride+w]&bit != 0 {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			continue
+		}
+
+		pivotRow := row(found)
+		if found != p {
+			// 行の入れ替え
+			for s, word := range row(p) {
+				pivotRow[s], m.data[p*stride+s] = word, pivotRow[s]
+			}
+			pivotRow = row(p)
+		}
+
+		// ピボット行のc列より左は0なので、cを含むワードから先だけをXORすればよい
+		for i := range m.rows {
+			if i != p && m.data[i*stride+w]&bit != 0 {
+				xorRow(row(i)[w:], pivotRow[w:])
+			}
+		}
+		pivots = append(pivots, c)
+	}
+	return pivots
+}
+
+// GF2Rankは、GF(2)上の階数を返す。
+func (m *Matrix) GF2Rank() (int, error) {
+	_, pivots, err := m.GF2RowEchelon()
+	if err != nil {
+		return 0, err
+	}
+	return len(pivots), nil
+}
+
+// GF2Detは、GF(2)上の行列式(0か1)を返す。正方行列であるべき。
+func (m *Matrix) GF2Det() (uint64, error) {
+	if err := m.validateSquare(); err != nil {
+		return 0, err
+	}
+
+	rank, err := m.GF2Rank()
+	if err != nil {
+		return 0, err
+	}
+
+	if rank == m.rows {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// GF2Inverseは、GF(2)上の逆行列を返す。正方行列でない場合と、正則でない場合はエラーを返す。
+// [A | I] を消去し、左半分が単位行列になれば、右半分が逆行列。
+func (m *Matrix) GF2Inverse() (*Matrix, error) {
+	if err := m.validateSquare(); err != nil {
+		return nil, err
+	}
+
+	n := m.rows
+	identity, err := NewZerosMatrix(n, n)
+	if err != nil {
+		return nil, err
+	}
+	for i := range n {
+		identity.data[i*identity.Stride()+i/64] |= 1 << uint(i%64)
+	}
+
+	augmented, err := HStack(m, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	if pivots := augmented.gf2Eliminate(n); len(pivots) < n {
+		return nil, fmt.Errorf("正則ではない: 階数 = %d, n = %d", len(pivots), n)
+	}
+	return augmented.SubMatrix(0, n, n, 2*n)
+}
+
+// GF2NullSpaceは、A·x = 0 の解空間の基底を返す。解が x = 0 だけの場合は空のスライスを返す。
+func (m *Matrix) GF2NullSpace() ([]*Vector, error) {
+	r, pivots, err := m.GF2RowEchelon()
+	if err != nil {
+		return nil, err
+	}
+
+	isPivot := make([]bool, m.cols)
+	for _, c := range pivots {
+		isPivot[c] = true
+	}
+
+	// 自由変数fを1、他の自由変数を0とすると、ピボットの変数は各行のf列の値に決まる
+	basis := make([]*Vector, 0, m.cols-len(pivots))
+	stride := r.Stride()
+	for f := range m.cols {
+		if isPivot[f] {
+			continue
+		}
+
+		v, err := NewVectorFromIndices(m.cols, []int{f})
+		if err != nil {
+			return nil, err
+		}
+		for i, c := range pivots {
+			if (r.data[i*stride+f/64]>>uint(f%64))&1 == 1 {
+				v.data[c/64] |= 1 << uint(c%64)
+			}
+		}
+		basis = append(basis, v)
+	}
+	return basis, nil
+}
+
+// GF2Solveは、A·x = b の解を1つ返す。自由変数は0とする。解が無い場合はエラーを返す。
+// len(b) = Rows であるべきで、xの長さはCols。
+func (m *Matrix) GF2Solve(b *Vector) (*Vector, error) {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	if b.n != m.rows {
+		return nil, fmt.Errorf("b.Len() = Rows であるべき: b.Len() = %d, Rows = %d", b.n, m.rows)
+	}
+
+	// bを列として右端に加えた [A | b] を消去する
+	column, err := NewZerosMatrix(m.rows, 1)
+	if err != nil {
+		return nil, err
+	}
+	for i := range m.rows {
+		column.data[i] = (b.data[i/64] >> uint(i%64)) & 1
+	}
+
+	augmented, err := HStack(m, column)
+	if err != nil {
+		return nil, err
+	}
+
+	pivots := augmented.gf2Eliminate(m.cols + 1)
+	if len(pivots) > 0 && pivots[len(pivots)-1] == m.cols {
+		return nil, fmt.Errorf("解が無い: 0 = 1 となる行がある")
+	}
+
+	x, err := NewVector(m.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := augmented.Stride()
+	for i, c := range pivots {
+		if (augmented.data[i*stride+m.cols/64]>>uint(m.cols%64))&1 == 1 {
+			x.data[c/64] |= 1 << uint(c%64)
+		}
+	}
+	return x, nil
+}
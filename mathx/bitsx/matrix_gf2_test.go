@@ -0,0 +1,351 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func toBitRows(t *testing.T, m *bitsx.Matrix) [][]uint64 {
+	t.Helper()
+	x := make([][]uint64, m.Rows())
+	for i := range m.Rows() {
+		x[i] = make([]uint64, m.Cols())
+		for j := range m.Cols() {
+			x[i][j] = mustBit(t, m, i, j)
+		}
+	}
+	return x
+}
+
+// ビット単位のガウス・ジョルダン消去による既約行階段形
+func naiveGF2RowEchelon(x [][]uint64) ([][]uint64, []int) {
+	r := make([][]uint64, len(x))
+	for i := range x {
+		r[i] = slices.Clone(x[i])
+	}
+
+	var pivots []int
+	cols := 0
+	if len(r) > 0 {
+		cols = len(r[0])
+	}
+	for c := 0; c < cols; c++ {
+		p := len(pivots)
+		found := -1
+		for i := p; i < len(r); i++ {
+			if r[i][c] == 1 {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			continue
+		}
+		r[p], r[found] = r[found], r[p]
+		for i := range r {
+			if i != p && r[i][c] == 1 {
+				for j := range cols {
+					r[i][j] ^= r[p][j]
+				}
+			}
+		}
+		pivots = append(pivots, c)
+	}
+	return r, pivots
+}
+
+func naiveGF2MulVec(x [][]uint64, v []uint64) []uint64 {
+	y := make([]uint64, len(x))
+	for i := range x {
+		for j := range v {
+			y[i] ^= x[i][j] & v[j]
+		}
+	}
+	return y
+}
+
+func vectorBits(t *testing.T, v *bitsx.Vector) []uint64 {
+	t.Helper()
+	x := make([]uint64, v.Len())
+	for i := range v.Len() {
+		bit, err := v.Bit(i)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		x[i] = bit
+	}
+	return x
+}
+
+func TestGF2Mul(t *testing.T) {
+	rng := rand.New(rand.NewPCG(111, 112))
+	// 行数128以上はMethod of Four Russiansの経路
+	shapes := []struct{ n, k, m int }{{1, 1, 1}, {3, 70, 5}, {10, 64, 130}, {130, 70, 65}, {200, 13, 3}, {128, 200, 64}}
+	for _, s := range shapes {
+		for _, density := range []int{-2, 0, 2} {
+			a, err := bitsx.NewRandMatrix(s.n, s.k, density, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			b, err := bitsx.NewRandMatrix(s.k, s.m, density, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			got, err := bitsx.GF2Mul(a, b)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+
+			x, y := toBitRows(t, a), toBitRows(t, b)
+			for i := range s.n {
+				for j := range s.m {
+					var want uint64
+					for k := range s.k {
+						want ^= x[i][k] & y[k][j]
+					}
+					if g := mustBit(t, got, i, j); g != want {
+						t.Fatalf("%v, density = %d, (%d, %d): got = %d, want = %d", s, density, i, j, g, want)
+					}
+				}
+			}
+		}
+	}
+
+	t.Run("異常_形状の不一致", func(t *testing.T) {
+		a, err := bitsx.NewZerosMatrix(2, 3)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := bitsx.GF2Mul(a, a); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestMatrixGF2RowEchelon(t *testing.T) {
+	rng := rand.New(rand.NewPCG(113, 114))
+	shapes := []struct{ rows, cols int }{{1, 1}, {5, 3}, {3, 5}, {20, 20}, {70, 65}, {65, 130}, {130, 70}}
+	for _, s := range shapes {
+		for _, density := range []int{-3, 0, 3} {
+			m, err := bitsx.NewRandMatrix(s.rows, s.cols, density, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			original := m.Clone()
+
+			got, pivots, err := m.GF2RowEchelon()
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			wantRows, wantPivots := naiveGF2RowEchelon(toBitRows(t, m))
+			if !slices.Equal(pivots, wantPivots) {
+				t.Fatalf("%v, density = %d: pivots = %v, want = %v", s, density, pivots, wantPivots)
+			}
+			if gotRows := toBitRows(t, got); !slices.EqualFunc(gotRows, wantRows, slices.Equal) {
+				t.Fatalf("%v, density = %d: 既約行階段形の不一致", s, density)
+			}
+			if !m.Equal(original) {
+				t.Fatalf("元の行列が変更された")
+			}
+
+			rank, err := m.GF2Rank()
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if rank != len(wantPivots) {
+				t.Fatalf("%v, density = %d: rank = %d, want = %d", s, density, rank, len(wantPivots))
+			}
+		}
+	}
+}
+
+func TestMatrixGF2Inverse(t *testing.T) {
+	rng := rand.New(rand.NewPCG(115, 116))
+	invertible := 0
+	for _, n := range []int{1, 2, 5, 64, 65, 100} {
+		for range 8 {
+			m, err := bitsx.NewRandMatrix(n, n, 0, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			_, pivots := naiveGF2RowEchelon(toBitRows(t, m))
+			singular := len(pivots) < n
+
+			det, err := m.GF2Det()
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			want := uint64(1)
+			if singular {
+				want = 0
+			}
+			if det != want {
+				t.Fatalf("n = %d: det = %d, want = %d", n, det, want)
+			}
+
+			inv, err := m.GF2Inverse()
+			if singular {
+				if err == nil {
+					t.Fatalf("エラーを期待したが、nilが返された")
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			invertible++
+
+			for _, pair := range [][2]*bitsx.Matrix{{m, inv}, {inv, m}} {
+				got, err := bitsx.GF2Mul(pair[0], pair[1])
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				for i := range n {
+					for j := range n {
+						want := uint64(0)
+						if i == j {
+							want = 1
+						}
+						if g := mustBit(t, got, i, j); g != want {
+							t.Fatalf("n = %d: 単位行列にならない: (%d, %d) = %d", n, i, j, g)
+						}
+					}
+				}
+			}
+		}
+	}
+	if invertible == 0 {
+		t.Fatalf("正則な行列が1つも生成されなかった")
+	}
+
+	t.Run("異常_正方行列でない", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(2, 3)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.GF2Inverse(); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.GF2Det(); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestMatrixGF2NullSpace(t *testing.T) {
+	rng := rand.New(rand.NewPCG(117, 118))
+	shapes := []struct{ rows, cols int }{{1, 1}, {3, 5}, {5, 3}, {20, 70}, {65, 130}, {70, 70}}
+	for _, s := range shapes {
+		for _, density := range []int{-3, 0, 3} {
+			m, err := bitsx.NewRandMatrix(s.rows, s.cols, density, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			x := toBitRows(t, m)
+			_, pivots := naiveGF2RowEchelon(x)
+
+			basis, err := m.GF2NullSpace()
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if want := s.cols - len(pivots); len(basis) != want {
+				t.Fatalf("%v, density = %d: len(basis) = %d, want = %d", s, density, len(basis), want)
+			}
+
+			vs := make([][]uint64, len(basis))
+			for i, v := range basis {
+				vs[i] = vectorBits(t, v)
+				for j, y := range naiveGF2MulVec(x, vs[i]) {
+					if y != 0 {
+						t.Fatalf("%v, density = %d: A·basis[%d] の%d番目が0でない", s, density, i, j)
+					}
+				}
+			}
+			// 基底は1次独立
+			if _, p := naiveGF2RowEchelon(vs); len(p) != len(basis) {
+				t.Fatalf("%v, density = %d: 基底が1次独立でない", s, density)
+			}
+		}
+	}
+}
+
+func TestMatrixGF2Solve(t *testing.T) {
+	rng := rand.New(rand.NewPCG(119, 120))
+	shapes := []struct{ rows, cols int }{{1, 1}, {3, 5}, {5, 3}, {64, 64}, {70, 65}, {65, 130}}
+	for _, s := range shapes {
+		for _, density := range []int{-3, 0} {
+			m, err := bitsx.NewRandMatrix(s.rows, s.cols, density, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			x := toBitRows(t, m)
+			_, pivots := naiveGF2RowEchelon(x)
+
+			t.Run("正常_解がある", func(t *testing.T) {
+				x0, _ := newRandVector(t, rng, s.cols, 0.5)
+				want := naiveGF2MulVec(x, vectorBits(t, x0))
+				b, err := bitsx.NewVector(s.rows)
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				for i, bit := range want {
+					if bit == 1 {
+						if err := b.Set(i); err != nil {
+							t.Fatalf("予期せぬエラー: %v", err)
+						}
+					}
+				}
+
+				sol, err := m.GF2Solve(b)
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				if got := naiveGF2MulVec(x, vectorBits(t, sol)); !slices.Equal(got, want) {
+					t.Fatalf("%v: A·x = b を満たさない", s)
+				}
+			})
+
+			t.Run("正常_任意のb", func(t *testing.T) {
+				b, _ := newRandVector(t, rng, s.rows, 0.5)
+				augmented := make([][]uint64, s.rows)
+				bs := vectorBits(t, b)
+				for i := range x {
+					augmented[i] = append(slices.Clone(x[i]), bs[i])
+				}
+				_, augPivots := naiveGF2RowEchelon(augmented)
+				consistent := len(augPivots) == len(pivots)
+
+				sol, err := m.GF2Solve(b)
+				if !consistent {
+					if err == nil {
+						t.Fatalf("エラーを期待したが、nilが返された")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				if got := naiveGF2MulVec(x, vectorBits(t, sol)); !slices.Equal(got, bs) {
+					t.Fatalf("%v: A·x = b を満たさない", s)
+				}
+			})
+		}
+	}
+
+	t.Run("異常_長さの不一致", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(3, 4)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		b, err := bitsx.NewVector(4)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.GF2Solve(b); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
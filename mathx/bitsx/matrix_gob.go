@@ -10,11 +10,15 @@ type gobEncodedMatrix struct {
 	Rows int
 	Cols int
 	Data []uint64
+
+	// rank・selectのディレクトリはdataから一意に決まる為、有無だけを記録し、読み込み時に作り直す。
+	// 不正な索引を読み込む事も無い
+	HasRankDirectory bool
 }
 
 func (m *Matrix) GobEncode() ([]byte, error) {
 	buf := &bytes.Buffer{}
-	payload := gobEncodedMatrix{Rows: m.rows, Cols: m.cols, Data: m.data}
+	payload := gobEncodedMatrix{Rows: m.rows, Cols: m.cols, Data: m.data, HasRankDirectory: m.HasRankDirectory()}
 	if err := gob.NewEncoder(buf).Encode(payload); err != nil {
 		return nil, err
 	}
@@ -27,12 +31,15 @@ func (m *Matrix) GobDecode(b []byte) error {
 		return err
 	}
 
-	decoded := &Matrix{rows: payload.Rows, cols: payload.Cols, data: payload.Data}
+	decoded := &Matrix{rows: payload.Rows, cols: payload.Cols, data: payload.Data, gen: new(uint64)}
 	if err := decoded.validateDotAVX512Family(); err != nil {
 		return fmt.Errorf("デコードされたMatrixが不正: %w", err)
 	}
 
 	decoded.ApplyTailMask()
+	if payload.HasRankDirectory {
+		decoded.buildRanks()
+	}
 	*m = *decoded
 	return nil
 }
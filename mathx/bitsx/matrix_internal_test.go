@@ -2,6 +2,7 @@ package bitsx
 
 import (
 	"math"
+	"math/rand/v2"
 	"testing"
 )
 
@@ -139,3 +140,35 @@ func TestMatrixValidate(t *testing.T) {
 		}
 	})
 }
+
+// etfStateの差分による更新が、全ての組を数え直したETFCostと一致し続けるかを確かめる。
+func TestETFStateIncremental(t *testing.T) {
+	rng := rand.New(rand.NewPCG(77, 78))
+	ms := make(Matrices, 5)
+	for i := range ms {
+		m, err := NewRandMatrix(3, 70, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		ms[i] = m
+	}
+
+	state, err := newETFState(ms)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	for iter := range 200 {
+		flip := Flip{Index: rng.IntN(len(ms)), Row: rng.IntN(3), Col: rng.IntN(70)}
+		predicted := state.flipCost(flip)
+		state.apply(flip)
+
+		want, err := ms.ETFCost()
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if predicted != want || state.cost() != want {
+			t.Fatalf("%d回目: コストの不一致: flipCost = %v, cost = %v, want = %v", iter, predicted, state.cost(), want)
+		}
+	}
+}
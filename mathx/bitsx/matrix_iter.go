@@ -0,0 +1,55 @@
+package bitsx
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// SetBitsは、1のビットの(行, 列)を行優先の順に返す。
+// ワードごとに最下位の1を取り出す為、0のビットの数に依らず、1の数に比例する時間で済む。
+func (m *Matrix) SetBits() iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		stride := m.Stride()
+		for i, word := range m.data {
+			r := i / stride
+			base := (i % stride) * 64
+			for ; word != 0; word = ClearLowest(word) {
+				if !yield(r, base+bits.TrailingZeros64(word)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// RowWordsは、r行目のStride()個のワードを順に返す。端数ビットは0。
+// rが範囲外の場合は何も返さない。
+func (m *Matrix) RowWords(r int) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		if r < 0 || r >= m.rows {
+			return
+		}
+
+		stride := m.Stride()
+		for _, word := range m.data[r*stride : (r+1)*stride] {
+			if !yield(word) {
+				return
+			}
+		}
+	}
+}
+
+// Wordsは、全てのワードを行優先の順に、ScanRowsWordと同じ文脈と共に返す。
+func (m *Matrix) Words() iter.Seq2[MatrixWordContext, uint64] {
+	return func(yield func(MatrixWordContext, uint64) bool) {
+		stride := m.Stride()
+		for r := range m.rows {
+			for s := range stride {
+				ctx := m.wordContext(r, s, stride)
+				if !yield(ctx, m.data[ctx.WordIndex]) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestMatrixSetBits(t *testing.T) {
+	rng := rand.New(rand.NewPCG(89, 90))
+	m, err := bitsx.NewRandMatrix(4, 130, -1, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	var want [][2]int
+	for r := range m.Rows() {
+		for c := range m.Cols() {
+			if mustBit(t, m, r, c) == 1 {
+				want = append(want, [2]int{r, c})
+			}
+		}
+	}
+
+	var got [][2]int
+	for r, c := range m.SetBits() {
+		got = append(got, [2]int{r, c})
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("値の不一致: got = %v, want = %v", got, want)
+	}
+
+	t.Run("正常_途中で抜けられる", func(t *testing.T) {
+		n := 0
+		for range m.SetBits() {
+			n++
+			if n == 3 {
+				break
+			}
+		}
+		if n != 3 {
+			t.Errorf("反復回数の不一致: got = %d, want = 3", n)
+		}
+	})
+}
+
+func TestMatrixRowWords(t *testing.T) {
+	rng := rand.New(rand.NewPCG(91, 92))
+	m, err := bitsx.NewRandMatrix(3, 130, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	t.Run("正常_RowWords", func(t *testing.T) {
+		for r := range m.Rows() {
+			got := slices.Collect(m.RowWords(r))
+			if len(got) != m.Stride() {
+				t.Fatalf("%d行目のワード数の不一致: got = %d, want = %d", r, len(got), m.Stride())
+			}
+			for s, word := range got {
+				want, err := m.Word(r*m.Stride() + s)
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				if word != want {
+					t.Fatalf("(%d, %d)のワードの不一致", r, s)
+				}
+			}
+		}
+		if got := slices.Collect(m.RowWords(3)); len(got) != 0 {
+			t.Errorf("範囲外の行で値が返された: %v", got)
+		}
+	})
+}
+
+// 手で組み立てた文脈。ScanBitsが渡す転置後の列(colT)は col*Rows + Row になる。
+type wantWordContext struct {
+	row, wordIndex, colStart, colEnd int
+	isTail                           bool
+}
+
+func assertWordContext(t *testing.T, m *bitsx.Matrix, got bitsx.MatrixWordContext, want wantWordContext) {
+	t.Helper()
+	if got.Row != want.row || got.WordIndex != want.wordIndex || got.ColStart != want.colStart || got.ColEnd != want.colEnd || got.IsTail != want.isTail {
+		t.Fatalf("文脈の不一致: got = %+v, want = %+v", got, want)
+	}
+	offset := want.row * m.Cols()
+	if got.GlobalStart != offset+want.colStart || got.GlobalEnd != offset+want.colEnd {
+		t.Fatalf("GlobalStart, GlobalEndの不一致: got = (%d, %d), want = (%d, %d)", got.GlobalStart, got.GlobalEnd, offset+want.colStart, offset+want.colEnd)
+	}
+
+	n := 0
+	err := got.ScanBits(func(i, col, colT int) error {
+		if col != want.colStart+i || colT != col*m.Rows()+want.row {
+			t.Fatalf("ScanBitsの引数の不一致: (i, col, colT) = (%d, %d, %d)", i, col, colT)
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if n != want.colEnd-want.colStart {
+		t.Fatalf("ScanBitsの呼び出し回数の不一致: got = %d, want = %d", n, want.colEnd-want.colStart)
+	}
+}
+
+// (r, s)のワードを、各ビットから組み立てる。列数を超えるビットは0になる。
+func wantRowWord(t *testing.T, m *bitsx.Matrix, r, s int) uint64 {
+	t.Helper()
+	var word uint64
+	for c := s * 64; c < min((s+1)*64, m.Cols()); c++ {
+		word |= uint64(mustBit(t, m, r, c)) << uint(c%64)
+	}
+	return word
+}
+
+func TestMatrixWordContexts(t *testing.T) {
+	rng := rand.New(rand.NewPCG(95, 96))
+	tests := []struct {
+		name string
+		rows int
+		cols int
+		want []wantWordContext
+	}{
+		{
+			name: "正常_端数ビットあり",
+			rows: 2,
+			cols: 130,
+			want: []wantWordContext{
+				{row: 0, wordIndex: 0, colStart: 0, colEnd: 64},
+				{row: 0, wordIndex: 1, colStart: 64, colEnd: 128},
+				{row: 0, wordIndex: 2, colStart: 128, colEnd: 130, isTail: true},
+				{row: 1, wordIndex: 3, colStart: 0, colEnd: 64},
+				{row: 1, wordIndex: 4, colStart: 64, colEnd: 128},
+				{row: 1, wordIndex: 5, colStart: 128, colEnd: 130, isTail: true},
+			},
+		},
+		{
+			name: "正常_端数ビットなし",
+			rows: 2,
+			cols: 128,
+			want: []wantWordContext{
+				{row: 0, wordIndex: 0, colStart: 0, colEnd: 64},
+				{row: 0, wordIndex: 1, colStart: 64, colEnd: 128},
+				{row: 1, wordIndex: 2, colStart: 0, colEnd: 64},
+				{row: 1, wordIndex: 3, colStart: 64, colEnd: 128},
+			},
+		},
+		{
+			name: "正常_1ワードに満たない",
+			rows: 3,
+			cols: 5,
+			want: []wantWordContext{
+				{row: 0, wordIndex: 0, colStart: 0, colEnd: 5, isTail: true},
+				{row: 1, wordIndex: 1, colStart: 0, colEnd: 5, isTail: true},
+				{row: 2, wordIndex: 2, colStart: 0, colEnd: 5, isTail: true},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 全ビットを1にして、端数ビットが0に保たれることも確かめる
+			m, err := bitsx.NewOnesMatrix(tc.rows, tc.cols)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			noise, err := bitsx.NewRandMatrix(tc.rows, tc.cols, 0, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if err := m.XorInPlace(noise); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+
+			i := 0
+			for ctx, word := range m.Words() {
+				if i >= len(tc.want) {
+					t.Fatalf("ワード数が多すぎる: want = %d", len(tc.want))
+				}
+				assertWordContext(t, m, ctx, tc.want[i])
+				if want := wantRowWord(t, m, tc.want[i].row, tc.want[i].colStart/64); word != want {
+					t.Fatalf("%d番目のワードの不一致: got = %#x, want = %#x", i, word, want)
+				}
+				if ctx.IsTail && word&^m.TailMask() != 0 {
+					t.Fatalf("%d番目のワードの端数ビットが0でない: %#x", i, word)
+				}
+				i++
+			}
+			if i != len(tc.want) {
+				t.Fatalf("ワード数の不一致: got = %d, want = %d", i, len(tc.want))
+			}
+
+			i = 0
+			err = m.ScanRowsWord(nil, func(ctx bitsx.MatrixWordContext) error {
+				assertWordContext(t, m, ctx, tc.want[i])
+				i++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if i != len(tc.want) {
+				t.Fatalf("ワード数の不一致: got = %d, want = %d", i, len(tc.want))
+			}
+		})
+	}
+
+	t.Run("正常_ScanRowsWordは指定した行を順に走査する", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(3, 70)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want := []wantWordContext{
+			{row: 2, wordIndex: 4, colStart: 0, colEnd: 64},
+			{row: 2, wordIndex: 5, colStart: 64, colEnd: 70, isTail: true},
+			{row: 0, wordIndex: 0, colStart: 0, colEnd: 64},
+			{row: 0, wordIndex: 1, colStart: 64, colEnd: 70, isTail: true},
+			{row: 2, wordIndex: 4, colStart: 0, colEnd: 64},
+			{row: 2, wordIndex: 5, colStart: 64, colEnd: 70, isTail: true},
+		}
+
+		i := 0
+		err = m.ScanRowsWord([]int{2, 0, 2}, func(ctx bitsx.MatrixWordContext) error {
+			if i >= len(want) {
+				t.Fatalf("ワード数が多すぎる: want = %d", len(want))
+			}
+			assertWordContext(t, m, ctx, want[i])
+			i++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if i != len(want) {
+			t.Fatalf("ワード数の不一致: got = %d, want = %d", i, len(want))
+		}
+	})
+
+	t.Run("異常_ScanRowsWordの行が範囲外", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(3, 70)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		for _, rowIdxs := range [][]int{{3}, {0, -1}} {
+			if err := m.ScanRowsWord(rowIdxs, func(bitsx.MatrixWordContext) error { return nil }); err == nil {
+				t.Fatalf("エラーを期待したが、nilが返された: rowIdxs = %v", rowIdxs)
+			}
+		}
+	})
+}
+
+func TestMatrixIteratorsAllocs(t *testing.T) {
+	rng := rand.New(rand.NewPCG(93, 94))
+	m, err := bitsx.NewRandMatrix(8, 200, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	sum := 0
+	allocs := testing.AllocsPerRun(10, func() {
+		for r, c := range m.SetBits() {
+			sum += r + c
+		}
+		for word := range m.RowWords(1) {
+			sum += int(word & 1)
+		}
+		for ctx := range m.Words() {
+			sum += ctx.WordIndex
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("メモリ確保が発生した: %v 回", allocs)
+	}
+}
@@ -0,0 +1,139 @@
+package bitsx
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONFormatは、MatrixをJSONへ書き出す時のdataの表し方。
+// 読み込み時は、どちらの形式も受け付ける。
+type JSONFormat int
+
+const (
+	// {"rows":2,"cols":70,"words":"<base64>"}
+	// dataをリトルエンディアンのバイト列にして、base64で表す。小さく、速い。
+	JSONFormatWords JSONFormat = iota
+
+	// {"rows":2,"cols":70,"bits":["0110...","1001..."]}
+	// 各行を0/1の文字列で表す。人が読み書きしやすい。
+	JSONFormatBits
+)
+
+type jsonEncodedMatrix struct {
+	Rows  int      `json:"rows"`
+	Cols  int      `json:"cols"`
+	Words []byte   `json:"words,omitempty"`
+	Bits  []string `json:"bits,omitempty"`
+
+	// gobと同様に、rank・selectのディレクトリは有無だけを記録し、読み込み時に作り直す
+	HasRankDirectory bool `json:"hasRankDirectory,omitempty"`
+}
+
+// MarshalJSONはJSONFormatWordsで書き出す。
+func (m *Matrix) MarshalJSON() ([]byte, error) {
+	return m.MarshalJSONFormat(JSONFormatWords)
+}
+
+func (m *Matrix) MarshalJSONFormat(format JSONFormat) ([]byte, error) {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return nil, fmt.Errorf("エンコードするMatrixが不正: %w", err)
+	}
+
+	payload := jsonEncodedMatrix{Rows: m.rows, Cols: m.cols, HasRankDirectory: m.HasRankDirectory()}
+	switch format {
+	case JSONFormatWords:
+		words := make([]byte, 0, 8*len(m.data))
+		for _, word := range m.data {
+			words = binary.LittleEndian.AppendUint64(words, word)
+		}
+		payload.Words = words
+	case JSONFormatBits:
+		payload.Bits = make([]string, m.rows)
+		var sb strings.Builder
+		stride := m.Stride()
+		for r := range m.rows {
+			sb.Reset()
+			row := m.data[r*stride : (r+1)*stride]
+			for c := range m.cols {
+				sb.WriteByte('0' + byte((row[c/64]>>uint(c%64))&1))
+			}
+			payload.Bits[r] = sb.String()
+		}
+	default:
+		return nil, fmt.Errorf("未対応のJSONFormat: %d", format)
+	}
+	return json.Marshal(payload)
+}
+
+func (m *Matrix) UnmarshalJSON(b []byte) error {
+	var payload jsonEncodedMatrix
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return err
+	}
+
+	if payload.Words != nil && payload.Bits != nil {
+		return fmt.Errorf("wordsとbitsのどちらか一方だけを指定するべき")
+	}
+
+	var decoded *Matrix
+	if payload.Bits != nil {
+		parsed, err := parseRows(payload.Bits)
+		if err != nil {
+			return fmt.Errorf("bitsが不正: %w", err)
+		}
+		if parsed.rows != payload.Rows || parsed.cols != payload.Cols {
+			return fmt.Errorf("bitsの形状がrows・colsと不一致: (%d x %d) vs (%d x %d)",
+				parsed.rows, parsed.cols, payload.Rows, payload.Cols)
+		}
+		decoded = parsed
+	} else {
+		if len(payload.Words)%8 != 0 {
+			return fmt.Errorf("wordsのバイト数が8の倍数ではない: %d", len(payload.Words))
+		}
+		data := make([]uint64, len(payload.Words)/8)
+		for i := range data {
+			data[i] = binary.LittleEndian.Uint64(payload.Words[8*i:])
+		}
+		decoded = &Matrix{rows: payload.Rows, cols: payload.Cols, data: data, gen: new(uint64)}
+	}
+
+	if err := decoded.validateDotAVX512Family(); err != nil {
+		return fmt.Errorf("デコードされたMatrixが不正: %w", err)
+	}
+
+	decoded.ApplyTailMask()
+	if payload.HasRankDirectory {
+		decoded.buildRanks()
+	}
+	*m = *decoded
+	return nil
+}
+
+// BitsJSONMatrixは、JSONFormatBitsで書き出すMatrix。
+// jsonx.Saveで保存する構造体のフィールドを、人が読める形式にしたい場合に使う。
+type BitsJSONMatrix struct {
+	*Matrix
+}
+
+func (m BitsJSONMatrix) MarshalJSON() ([]byte, error) {
+	if m.Matrix == nil {
+		return []byte("null"), nil
+	}
+	return m.Matrix.MarshalJSONFormat(JSONFormatBits)
+}
+
+func (m *BitsJSONMatrix) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		m.Matrix = nil
+		return nil
+	}
+
+	decoded := &Matrix{}
+	if err := decoded.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	m.Matrix = decoded
+	return nil
+}
@@ -0,0 +1,128 @@
+package bitsx_test
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+
+	"github.com/sw965/omw/encoding/jsonx"
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestMatrixJSONRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(41, 42))
+
+	type experiment struct {
+		Name     string
+		Encoding *bitsx.Matrix
+		Codebook bitsx.Matrices
+		Readable bitsx.BitsJSONMatrix
+	}
+
+	a, b := newRandMatrixPair(t, 3, 130, rng)
+	c, err := bitsx.NewRandMatrix(2, 5, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	want := experiment{
+		Name:     "rff",
+		Encoding: a,
+		Codebook: bitsx.Matrices{b, c},
+		Readable: bitsx.BitsJSONMatrix{Matrix: c},
+	}
+
+	path := filepath.Join(t.TempDir(), "experiment.json")
+	if err := jsonx.Save(want, path); err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	got, err := jsonx.Load[experiment](path)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	if !got.Encoding.Equal(want.Encoding) {
+		t.Errorf("Encodingが往復で変化した")
+	}
+	if len(got.Codebook) != 2 || !got.Codebook[0].Equal(b) || !got.Codebook[1].Equal(c) {
+		t.Errorf("Codebookが往復で変化した")
+	}
+	if !got.Readable.Equal(c) {
+		t.Errorf("Readableが往復で変化した")
+	}
+}
+
+func TestMatrixMarshalJSONFormat(t *testing.T) {
+	m, err := bitsx.ParseMatrix("[10010 01100]")
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	t.Run("正常_bits形式", func(t *testing.T) {
+		got, err := m.MarshalJSONFormat(bitsx.JSONFormatBits)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want := `{"rows":2,"cols":5,"bits":["10010","01100"]}`
+		if string(got) != want {
+			t.Errorf("値の不一致: got = %s, want = %s", got, want)
+		}
+	})
+
+	t.Run("正常_words形式", func(t *testing.T) {
+		got, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		// 1行目 = 0b01001 = 9, 2行目 = 0b00110 = 6 をリトルエンディアンの8バイトずつ
+		want := `{"rows":2,"cols":5,"words":"CQAAAAAAAAAGAAAAAAAAAA=="}`
+		if string(got) != want {
+			t.Errorf("値の不一致: got = %s, want = %s", got, want)
+		}
+	})
+
+	t.Run("異常_未対応の形式", func(t *testing.T) {
+		if _, err := m.MarshalJSONFormat(bitsx.JSONFormat(99)); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestMatrixUnmarshalJSONInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"Rowsが0", `{"rows":0,"cols":5,"words":""}`},
+		{"wordsの長さが不一致", `{"rows":2,"cols":5,"words":"CQAAAAAAAAA="}`},
+		{"wordsが8の倍数ではない", `{"rows":1,"cols":5,"words":"CQ=="}`},
+		{"bitsの形状が不一致", `{"rows":2,"cols":4,"bits":["10010","01100"]}`},
+		{"bitsの文字が不正", `{"rows":1,"cols":2,"bits":["1x"]}`},
+		{"wordsとbitsの両方", `{"rows":1,"cols":1,"words":"AQAAAAAAAAA=","bits":["1"]}`},
+		{"JSONとして不正", `{"rows":`},
+	}
+
+	for _, tt := range tests {
+		t.Run("異常_"+tt.name, func(t *testing.T) {
+			var m bitsx.Matrix
+			if err := json.Unmarshal([]byte(tt.json), &m); err == nil {
+				t.Fatalf("エラーを期待したが、nilが返された")
+			}
+		})
+	}
+
+	t.Run("正常_端数ビットは0にマスクされる", func(t *testing.T) {
+		var m bitsx.Matrix
+		if err := json.Unmarshal([]byte(`{"rows":1,"cols":2,"words":"//////////8="}`), &m); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err := m.Word(0)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got != 0b11 {
+			t.Errorf("値の不一致: got = %#x, want = 0x3", got)
+		}
+	})
+}
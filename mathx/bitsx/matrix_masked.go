@@ -0,0 +1,206 @@
+package bitsx
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// HammingDistanceMaskedは、maskが1の位置だけを比較したハミング距離を返す。
+// maskは1行(全ての行に共通の列マスク)であるか、mと同じ形状(要素ごとのマスク)であるべき。
+func (m *Matrix) HammingDistanceMasked(other, mask *Matrix) (int, error) {
+	if err := validateMaskedAVX512Args(m, other, mask); err != nil {
+		return 0, err
+	}
+
+	if mask.rows == m.rows {
+		return xorPopcntMasked(m.data, other.data, mask.data), nil
+	}
+
+	stride := m.Stride()
+	sum := 0
+	for r := range m.rows {
+		start := r * stride
+		end := start + stride
+		sum += xorPopcntMasked(m.data[start:end], other.data[start:end], mask.data)
+	}
+	return sum, nil
+}
+
+// DotMaskedは、maskが1の列だけを比較したDotを返す。maskは (1 x Cols) であるべき。
+func (m *Matrix) DotMasked(other, mask *Matrix) ([]int, error) {
+	resultsLen, err := validateDotMaskedAVX512Args(m, other, mask)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]int, resultsLen)
+	if err := m.DotMaskedInto(results, other, mask); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (m *Matrix) DotMaskedInto(dst []int, other, mask *Matrix) error {
+	resultsLen, err := validateDotMaskedAVX512Args(m, other, mask)
+	if err != nil {
+		return err
+	}
+
+	if err := validateDotResults(m.cols, resultsLen, dst); err != nil {
+		return err
+	}
+
+	maskOnes := 0
+	for _, w := range mask.data {
+		maskOnes += bits.OnesCount64(w)
+	}
+
+	leftRows := m.rows
+	rightRows := other.rows
+	stride := m.Stride()
+
+	if useAVX512 {
+		dotMaskedAVX512(&m.data[0], &other.data[0], &mask.data[0], leftRows, rightRows, maskOnes, stride, &dst[0])
+	} else {
+		dotMaskedGo(m.data, other.data, mask.data, leftRows, rightRows, maskOnes, stride, dst)
+	}
+	return nil
+}
+
+// HammingDistanceWeightedは、値が異なる列の重みweights[c]の総和を、全ての行について合計して返す。
+// len(weights) == Cols であるべき。重みは負でもよい。
+func (m *Matrix) HammingDistanceWeighted(other *Matrix, weights []int) (int, error) {
+	if err := m.ValidateSameShape(other); err != nil {
+		return 0, err
+	}
+
+	pos, neg, err := newWeightPlanes(weights, m.cols)
+	if err != nil {
+		return 0, err
+	}
+
+	sum := 0
+	for k, plane := range pos {
+		if plane == nil {
+			continue
+		}
+		d, err := m.HammingDistanceMasked(other, plane)
+		if err != nil {
+			return 0, err
+		}
+		sum += d << k
+	}
+
+	for k, plane := range neg {
+		if plane == nil {
+			continue
+		}
+		d, err := m.HammingDistanceMasked(other, plane)
+		if err != nil {
+			return 0, err
+		}
+		sum -= d << k
+	}
+	return sum, nil
+}
+
+// DotWeightedは、mの各行とotherの各行について、値が一致する列の重みweights[c]の総和を返す。
+// len(weights) == Cols であるべき。重みは負でもよい。
+func (m *Matrix) DotWeighted(other *Matrix, weights []int) ([]int, error) {
+	resultsLen, err := validateDotAVX512Args(m, other)
+	if err != nil {
+		return nil, err
+	}
+
+	pos, neg, err := newWeightPlanes(weights, m.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]int, resultsLen)
+	buf := make([]int, resultsLen)
+	accumulate := func(planes []*Matrix, sign int) error {
+		for k, plane := range planes {
+			if plane == nil {
+				continue
+			}
+			if err := m.DotMaskedInto(buf, other, plane); err != nil {
+				return err
+			}
+			for i, v := range buf {
+				results[i] += sign * (v << k)
+			}
+		}
+		return nil
+	}
+
+	if err := accumulate(pos, 1); err != nil {
+		return nil, err
+	}
+	if err := accumulate(neg, -1); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func xorPopcntMasked(a, b, mask []uint64) int {
+	if useAVX512 {
+		return xorPopcntMaskedAVX512(&a[0], &b[0], &mask[0], len(a))
+	}
+	return xorPopcntMaskedGo(a, b, mask)
+}
+
+// 重みを、2の冪ごとのビットプレーン(1 x cols の列マスク)に分解する。
+// weights[c] = Σ_k 2^k * pos[k][c] - Σ_k 2^k * neg[k][c] となり、
+// 重み付きの総和を、マスク付きのpopcountの重ね合わせとして求められる。
+// 1が1つも無いプレーンはnilになる。
+func newWeightPlanes(weights []int, cols int) (pos, neg []*Matrix, err error) {
+	if len(weights) != cols {
+		return nil, nil, fmt.Errorf("len(weights) == Cols であるべき: len(weights) = %d, Cols = %d", len(weights), cols)
+	}
+
+	planes := func(abs func(w int) uint64) ([]*Matrix, error) {
+		var ps []*Matrix
+		for c, w := range weights {
+			u := abs(w)
+			for u != 0 {
+				k := bits.TrailingZeros64(u)
+				for len(ps) <= k {
+					ps = append(ps, nil)
+				}
+				if ps[k] == nil {
+					p, err := NewZerosMatrix(1, cols)
+					if err != nil {
+						return nil, err
+					}
+					ps[k] = p
+				}
+				ps[k].data[c/64] |= 1 << uint(c%64)
+				u = ClearLowest(u)
+			}
+		}
+		return ps, nil
+	}
+
+	pos, err = planes(func(w int) uint64 {
+		if w > 0 {
+			return uint64(w)
+		}
+		return 0
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	neg, err = planes(func(w int) uint64 {
+		if w < 0 {
+			// math.MinIntも、uint64として2の補数を取れば絶対値になる
+			return -uint64(w)
+		}
+		return 0
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return pos, neg, nil
+}
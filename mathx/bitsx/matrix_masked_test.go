@@ -0,0 +1,214 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func mustBit(t *testing.T, m *bitsx.Matrix, r, c int) uint64 {
+	t.Helper()
+	bit, err := m.Bit(r, c)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	return bit
+}
+
+// 素朴(愚直)な実装で、重み付きの一致数を求める
+func naiveDotWeighted(t *testing.T, left, right *bitsx.Matrix, weights []int) []int {
+	t.Helper()
+	results := make([]int, 0, left.Rows()*right.Rows())
+	for i := range left.Rows() {
+		for j := range right.Rows() {
+			sum := 0
+			for c := range left.Cols() {
+				if mustBit(t, left, i, c) == mustBit(t, right, j, c) {
+					sum += weights[c]
+				}
+			}
+			results = append(results, sum)
+		}
+	}
+	return results
+}
+
+func TestMatrixHammingDistanceMasked(t *testing.T) {
+	rng := rand.New(rand.NewPCG(21, 22))
+
+	for _, cols := range []int{1, 64, 65, 600} {
+		a, b := newRandMatrixPair(t, 4, cols, rng)
+		rowMask, err := bitsx.NewRandMatrix(1, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		elemMask, err := bitsx.NewRandMatrix(4, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		wantRow, wantElem := 0, 0
+		for r := range 4 {
+			for c := range cols {
+				diff := mustBit(t, a, r, c) ^ mustBit(t, b, r, c)
+				wantRow += int(diff & mustBit(t, rowMask, 0, c))
+				wantElem += int(diff & mustBit(t, elemMask, r, c))
+			}
+		}
+
+		got, err := a.HammingDistanceMasked(b, rowMask)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got != wantRow {
+			t.Errorf("cols = %d: 列マスクの値の不一致: got = %d, want = %d", cols, got, wantRow)
+		}
+
+		got, err = a.HammingDistanceMasked(b, elemMask)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got != wantElem {
+			t.Errorf("cols = %d: 要素マスクの値の不一致: got = %d, want = %d", cols, got, wantElem)
+		}
+
+		// 全て1のマスクなら、HammingDistanceと一致する
+		ones, err := bitsx.NewOnesMatrix(1, cols)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err = a.HammingDistanceMasked(b, ones)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want, err := a.HammingDistance(b)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got != want {
+			t.Errorf("cols = %d: 全て1のマスクでHammingDistanceと一致しない: got = %d, want = %d", cols, got, want)
+		}
+	}
+
+	t.Run("異常_マスクの形状が不正", func(t *testing.T) {
+		a, err := bitsx.NewZerosMatrix(3, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		for _, shape := range []struct{ rows, cols int }{{2, 10}, {1, 11}} {
+			mask, err := bitsx.NewZerosMatrix(shape.rows, shape.cols)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if _, err := a.HammingDistanceMasked(a, mask); err == nil {
+				t.Fatalf("(%d, %d): エラーを期待したが、nilが返された", shape.rows, shape.cols)
+			}
+		}
+	})
+}
+
+func TestMatrixDotMasked(t *testing.T) {
+	rng := rand.New(rand.NewPCG(23, 24))
+
+	for _, cols := range []int{1, 64, 65, 600} {
+		left, err := bitsx.NewRandMatrix(3, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		right, err := bitsx.NewRandMatrix(5, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		mask, err := bitsx.NewRandMatrix(1, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		weights := make([]int, cols)
+		for c := range cols {
+			weights[c] = int(mustBit(t, mask, 0, c))
+		}
+
+		got, err := left.DotMasked(right, mask)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if want := naiveDotWeighted(t, left, right, weights); !slices.Equal(got, want) {
+			t.Errorf("cols = %d: 値の不一致: got = %v, want = %v", cols, got, want)
+		}
+	}
+
+	t.Run("異常_マスクが1行ではない", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.DotMasked(m, m); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestMatrixWeighted(t *testing.T) {
+	rng := rand.New(rand.NewPCG(25, 26))
+
+	for _, cols := range []int{1, 64, 130} {
+		left, err := bitsx.NewRandMatrix(3, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		right, err := bitsx.NewRandMatrix(4, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		// 0や負の重みも含める
+		weights := make([]int, cols)
+		for c := range cols {
+			weights[c] = rng.IntN(2001) - 1000
+		}
+
+		got, err := left.DotWeighted(right, weights)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if want := naiveDotWeighted(t, left, right, weights); !slices.Equal(got, want) {
+			t.Errorf("cols = %d: DotWeightedの値の不一致: got = %v, want = %v", cols, got, want)
+		}
+
+		other, err := bitsx.NewRandMatrix(3, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want := 0
+		for r := range 3 {
+			for c := range cols {
+				if mustBit(t, left, r, c) != mustBit(t, other, r, c) {
+					want += weights[c]
+				}
+			}
+		}
+		gotDistance, err := left.HammingDistanceWeighted(other, weights)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if gotDistance != want {
+			t.Errorf("cols = %d: HammingDistanceWeightedの値の不一致: got = %d, want = %d", cols, gotDistance, want)
+		}
+	}
+
+	t.Run("異常_重みの長さが不一致", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.DotWeighted(m, make([]int, 9)); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.HammingDistanceWeighted(m, make([]int, 11)); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package bitsx
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+type mulVecElem interface {
+	int | float32
+}
+
+// MulVecは、各行について、ビットが1である列のxの合計を返す。xの長さはColsであるべき。
+func (m *Matrix) MulVec(x []int) ([]int, error) {
+	return mulVec(m, x, false)
+}
+
+// MulVecFloat32は、MulVecのfloat32版。1のビットの位置のxを直接足すが、ワードごとの部分和を合計する為、
+// 列の順に足した結果とは丸め誤差が異なる場合がある。
+func (m *Matrix) MulVecFloat32(x []float32) ([]float32, error) {
+	return mulVec(m, x, false)
+}
+
+// MulVecBipolarは、ビットの1を+1、0を-1とみなし、各行とxの内積を返す。
+// intでは Σ(1の列のx) - Σ(0の列のx) = 2*MulVec - Σx として求める。
+// float32では、桁落ちを避ける為、1の列のxを足して0の列のxを引く(丸め誤差はMulVecFloat32と同様)。
+func (m *Matrix) MulVecBipolar(x []int) ([]int, error) {
+	return mulVec(m, x, true)
+}
+
+func (m *Matrix) MulVecBipolarFloat32(x []float32) ([]float32, error) {
+	return mulVec(m, x, true)
+}
+
+// MulVecBatchは、xsの各ベクトルについてMulVecを求める。結果の[i][r]は、xs[i]とr行目の積。
+// 行列を1度だけ走査する為、MulVecを繰り返し呼ぶより速い。
+func (m *Matrix) MulVecBatch(xs [][]int) ([][]int, error) {
+	return mulVecBatch(m, xs, false)
+}
+
+func (m *Matrix) MulVecBatchFloat32(xs [][]float32) ([][]float32, error) {
+	return mulVecBatch(m, xs, false)
+}
+
+func (m *Matrix) MulVecBipolarBatch(xs [][]int) ([][]int, error) {
+	return mulVecBatch(m, xs, true)
+}
+
+func (m *Matrix) MulVecBipolarBatchFloat32(xs [][]float32) ([][]float32, error) {
+	return mulVecBatch(m, xs, true)
+}
+
+func mulVec[T mulVecElem](m *Matrix, x []T, bipolar bool) ([]T, error) {
+	results, err := mulVecBatch(m, [][]T{x}, bipolar)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// 1ワードずつ、1のビットの位置のxを足す。
+// intでは、1が半分を超えるワードは、ワード内のxの合計から0のビットの位置のxを引く。
+// どちらの場合も、1ワードあたりの加算は32回以下で済む。
+// float32では、この引き算で大きさの異なる値が打ち消され、小さい値が失われる為、常に1のビットの位置のxを足す。
+func mulVecBatch[T mulVecElem](m *Matrix, xs [][]T, bipolar bool) ([][]T, error) {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	if len(xs) == 0 {
+		return nil, fmt.Errorf("len(xs) > 0 であるべき")
+	}
+
+	for i, x := range xs {
+		if len(x) != m.cols {
+			return nil, fmt.Errorf("len(xs[%d]) = Cols であるべき: len(xs[%d]) = %d, Cols = %d", i, i, len(x), m.cols)
+		}
+	}
+
+	var zero T
+	_, exact := any(zero).(int)
+
+	stride := m.Stride()
+	tailMask := m.TailMask()
+
+	// ベクトルごとの、ワード単位の合計と全体の合計(intでのみ使う)
+	var wordSums [][]T
+	var totals []T
+	if exact {
+		wordSums = make([][]T, len(xs))
+		totals = make([]T, len(xs))
+		for i, x := range xs {
+			wordSums[i] = make([]T, stride)
+			for c, v := range x {
+				wordSums[i][c/64] += v
+				totals[i] += v
+			}
+		}
+	}
+
+	results := make([][]T, len(xs))
+	for i := range results {
+		results[i] = make([]T, m.rows)
+	}
+
+	for r := range m.rows {
+		row := m.data[r*stride : (r+1)*stride]
+		for s, word := range row {
+			valid := ^uint64(0)
+			if s == stride-1 {
+				valid = tailMask
+			}
+
+			base := s * 64
+			if !exact {
+				for i, x := range xs {
+					var acc T
+					for w := word; w != 0; w = ClearLowest(w) {
+						acc += x[base+bits.TrailingZeros64(w)]
+					}
+					if bipolar {
+						for w := ^word & valid; w != 0; w = ClearLowest(w) {
+							acc -= x[base+bits.TrailingZeros64(w)]
+						}
+					}
+					results[i][r] += acc
+				}
+				continue
+			}
+
+			complement := 2*bits.OnesCount64(word) > bits.OnesCount64(valid)
+			target := word
+			if complement {
+				target = ^word & valid
+			}
+
+			for i, x := range xs {
+				var acc T
+				for w := target; w != 0; w = ClearLowest(w) {
+					acc += x[base+bits.TrailingZeros64(w)]
+				}
+				if complement {
+					acc = wordSums[i][s] - acc
+				}
+				results[i][r] += acc
+			}
+		}
+	}
+
+	if exact && bipolar {
+		for i, total := range totals {
+			for r := range results[i] {
+				results[i][r] = 2*results[i][r] - total
+			}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,192 @@
+package bitsx_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestMatrixMulVec(t *testing.T) {
+	rng := rand.New(rand.NewPCG(55, 56))
+
+	// k = 2 で1の多いワード、k = -2 で0の多いワードが両方現れる
+	for _, cols := range []int{1, 63, 64, 100, 200} {
+		for _, k := range []int{-2, 0, 2} {
+			m, err := bitsx.NewRandMatrix(5, cols, k, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+
+			xs := make([][]int, 3)
+			fxs := make([][]float32, 3)
+			for i := range xs {
+				xs[i] = make([]int, cols)
+				fxs[i] = make([]float32, cols)
+				for c := range cols {
+					// float32でも誤差無く表せる整数値にする
+					xs[i][c] = rng.IntN(201) - 100
+					fxs[i][c] = float32(xs[i][c])
+				}
+			}
+
+			want := make([][]int, 3)
+			wantBipolar := make([][]int, 3)
+			for i, x := range xs {
+				want[i] = make([]int, 5)
+				wantBipolar[i] = make([]int, 5)
+				for r := range 5 {
+					for c, v := range x {
+						if mustBit(t, m, r, c) == 1 {
+							want[i][r] += v
+							wantBipolar[i][r] += v
+						} else {
+							wantBipolar[i][r] -= v
+						}
+					}
+				}
+			}
+
+			got, err := m.MulVec(xs[0])
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if !slices.Equal(got, want[0]) {
+				t.Fatalf("cols = %d, k = %d: MulVecの不一致: got = %v, want = %v", cols, k, got, want[0])
+			}
+
+			gotBipolar, err := m.MulVecBipolar(xs[0])
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if !slices.Equal(gotBipolar, wantBipolar[0]) {
+				t.Fatalf("cols = %d, k = %d: MulVecBipolarの不一致: got = %v, want = %v", cols, k, gotBipolar, wantBipolar[0])
+			}
+
+			batch, err := m.MulVecBatch(xs)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			batchBipolar, err := m.MulVecBipolarBatch(xs)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			fbatch, err := m.MulVecBatchFloat32(fxs)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			fbatchBipolar, err := m.MulVecBipolarBatchFloat32(fxs)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			for i := range xs {
+				if !slices.Equal(batch[i], want[i]) {
+					t.Fatalf("cols = %d, k = %d: MulVecBatch[%d]の不一致", cols, k, i)
+				}
+				if !slices.Equal(batchBipolar[i], wantBipolar[i]) {
+					t.Fatalf("cols = %d, k = %d: MulVecBipolarBatch[%d]の不一致", cols, k, i)
+				}
+				for r := range 5 {
+					if fbatch[i][r] != float32(want[i][r]) || fbatchBipolar[i][r] != float32(wantBipolar[i][r]) {
+						t.Fatalf("cols = %d, k = %d: float32の[%d][%d]の不一致", cols, k, i, r)
+					}
+				}
+			}
+		}
+	}
+
+	t.Run("正常_float32", func(t *testing.T) {
+		m, err := bitsx.ParseMatrix("[101 011]")
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err := m.MulVecFloat32([]float32{0.5, 1.25, -2})
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if want := []float32{-1.5, -0.75}; !slices.Equal(got, want) {
+			t.Errorf("値の不一致: got = %v, want = %v", got, want)
+		}
+		got, err = m.MulVecBipolarFloat32([]float32{0.5, 1.25, -2})
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if want := []float32{-2.75, -1.25}; !slices.Equal(got, want) {
+			t.Errorf("値の不一致: got = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("正常_float32_大きさの異なる重み", func(t *testing.T) {
+		m, err := bitsx.ParseMatrix("[0111]")
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err := m.MulVecFloat32([]float32{1e8, 1, 1, 1})
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if want := []float32{3}; !slices.Equal(got, want) {
+			t.Errorf("値の不一致: got = %v, want = %v", got, want)
+		}
+
+		// 1の多い行で、0のビットの位置に置いた大きな重みが、1の列の小さな重みを打ち消さない事を確かめる
+		const cols = 200
+		for range 8 {
+			m, err := bitsx.NewRandMatrix(1, cols, 2, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			x := make([]float32, cols)
+			for c := range x {
+				x[c] = rng.Float32() - 0.5
+				if mustBit(t, m, 0, c) == 0 && rng.IntN(2) == 0 {
+					x[c] = 1e8 * (rng.Float32() + 1)
+				}
+			}
+			for _, bipolar := range []bool{false, true} {
+				var got []float32
+				if bipolar {
+					got, err = m.MulVecBipolarFloat32(x)
+				} else {
+					got, err = m.MulVecFloat32(x)
+				}
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				var want, scale float64
+				for c, v := range x {
+					switch {
+					case mustBit(t, m, 0, c) == 1:
+						want += float64(v)
+					case bipolar:
+						want -= float64(v)
+					default:
+						continue
+					}
+					scale += math.Abs(float64(v))
+				}
+				if diff := math.Abs(float64(got[0]) - want); diff > 1e-5*scale {
+					t.Fatalf("bipolar = %v: got = %v, want = %v", bipolar, got[0], want)
+				}
+			}
+		}
+	})
+
+	t.Run("異常_長さの不一致", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.MulVec(make([]int, 9)); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.MulVecBatchFloat32([][]float32{make([]float32, 10), make([]float32, 11)}); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.MulVecBatch(nil); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
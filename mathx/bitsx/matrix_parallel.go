@@ -0,0 +1,77 @@
+package bitsx
+
+import (
+	"github.com/sw965/omw/parallel"
+)
+
+const (
+	// 右側のタイルの大きさ(バイト)。一般的なL2(256KiB以上)の半分に収め、左側の行の読み込みと共存させる
+	dotTileBytes = 128 * 1024
+
+	// 左側のタイルの行数。タイル数を増やして、コア間の負荷の偏りを小さくする
+	dotTileLeftRows = 32
+)
+
+// DotParallelはDotと同じ結果を、p個のgoroutineで求める。
+// 左右の行をキャッシュに収まる大きさのタイルに分け、タイル単位でparallel.Forへ割り振る。
+// 各結果は直列版と同じカーネルで求める為、結果はDotとビット単位で一致する。
+func (m *Matrix) DotParallel(other *Matrix, p int) ([]int, error) {
+	resultsLen, err := validateDotAVX512Args(m, other)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]int, resultsLen)
+	if err := m.DotParallelInto(results, other, p); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (m *Matrix) DotParallelInto(dst []int, other *Matrix, p int) error {
+	resultsLen, err := validateDotAVX512Args(m, other)
+	if err != nil {
+		return err
+	}
+
+	if err := validateDotResults(m.cols, resultsLen, dst); err != nil {
+		return err
+	}
+
+	leftRows := m.rows
+	rightRows := other.rows
+	cols := m.cols
+	stride := m.Stride()
+
+	rightTileRows := max(dotTileBytes/(stride*8), 1)
+	leftTiles := (leftRows + dotTileLeftRows - 1) / dotTileLeftRows
+	rightTiles := (rightRows + rightTileRows - 1) / rightTileRows
+
+	return parallel.For(leftTiles*rightTiles, p, func(workerID, idx int) error {
+		// 同じ右タイルを使うタイルが、同じworkerに連続して割り振られるようにする
+		rightStart := (idx / leftTiles) * rightTileRows
+		rightEnd := min(rightStart+rightTileRows, rightRows)
+		leftStart := (idx % leftTiles) * dotTileLeftRows
+		leftEnd := min(leftStart+dotTileLeftRows, leftRows)
+
+		tileRightRows := rightEnd - rightStart
+		rightData := other.data[rightStart*stride : rightEnd*stride]
+
+		// 結果の1行のうち、このタイルが担う範囲は連続している為、左の1行ずつカーネルを呼ぶ。
+		// leftRows=1 として部分スライスを渡すので、カーネルの長さの制約を満たす
+		for r := leftStart; r < leftEnd; r++ {
+			leftData := m.data[r*stride : (r+1)*stride]
+			resultsStart := r*rightRows + rightStart
+			tileResults := dst[resultsStart : resultsStart+tileRightRows]
+			switch {
+			case useAVX512:
+				dotAVX512(&leftData[0], &rightData[0], 1, tileRightRows, cols, stride, &tileResults[0])
+			case useAVX2:
+				dotAVX2(&leftData[0], &rightData[0], 1, tileRightRows, cols, stride, &tileResults[0])
+			default:
+				dotGo(leftData, rightData, 1, tileRightRows, cols, stride, tileResults)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestMatrixDotParallel(t *testing.T) {
+	rng := rand.New(rand.NewPCG(19, 20))
+
+	// cols=65536 なら1行8KiBとなり、右側は16行ずつのタイルに分かれる。
+	// 行数を割り切れない値にして、端のタイルも確かめる
+	shapes := []struct{ leftRows, rightRows, cols int }{
+		{1, 1, 1},
+		{3, 70, 130},
+		{70, 3, 64},
+		{37, 41, 65536},
+	}
+
+	for _, s := range shapes {
+		left, err := bitsx.NewRandMatrix(s.leftRows, s.cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		right, err := bitsx.NewRandMatrix(s.rightRows, s.cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		want, err := left.Dot(right)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		for _, p := range []int{1, 3, 8} {
+			got, err := left.DotParallel(right, p)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("shape (%d, %d, %d), p = %d: Dotと一致しない", s.leftRows, s.rightRows, s.cols, p)
+			}
+		}
+	}
+
+	t.Run("異常_pが1未満", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.DotParallel(m, 0); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+
+	t.Run("異常_dstの長さが不一致", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := m.DotParallelInto(make([]int, 3), m, 2); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package bitsx
+
+import (
+	"fmt"
+)
+
+// rank・selectのディレクトリは、dataの全体を1本のビット列とみなして作る。
+// 各行の先頭は64ビット境界に揃っており、端数ビットは0なので、行rのrankは
+// 行の先頭までの1の数を引くだけで求まる。
+//
+// ディレクトリはdataから一意に決まる。ビットを変更するメソッド(Set・Clear・Toggle・SetWord・〇〇Into・〇〇InPlace)は
+// ディレクトリを破棄する。RowViewで元の行列とdataを共有する行列は、書き込みの世代を共有する為、
+// どれか1つへの書き込みで、他の全てのディレクトリも無効になる。
+//
+// gob・バイナリ形式・JSONは、いずれもディレクトリの有無を記録し、読み込み時に作り直す。Cloneは引き継がない。
+
+// BuildRankDirectoryは、Rank1・Select1の為のディレクトリを作る。既にあれば何もしない。
+// 追加のメモリは、dataのおよそ1/4。
+func (m *Matrix) BuildRankDirectory() error {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return err
+	}
+
+	if !m.HasRankDirectory() {
+		m.buildRanks()
+	}
+	return nil
+}
+
+// HasRankDirectoryは、有効なディレクトリがあるかを返す。
+// RowViewでdataを共有する別の行列へ書き込まれた場合は、無効になっている。
+func (m *Matrix) HasRankDirectory() bool {
+	return m.ranks != nil && (m.gen == nil || m.ranks.gen == *m.gen)
+}
+
+func (m *Matrix) DropRankDirectory() {
+	m.ranks = nil
+}
+
+func (m *Matrix) buildRanks() {
+	idx := newRankIndex(m.data)
+	if m.gen != nil {
+		idx.gen = *m.gen
+	}
+	m.ranks = idx
+}
+
+// dataへ書き込んだ時に呼ぶ。dataを共有する全ての行列のディレクトリも無効にする。
+func (m *Matrix) invalidateRanks() {
+	m.ranks = nil
+	if m.gen != nil {
+		*m.gen++
+	}
+}
+
+func (m *Matrix) validateRankDirectory(r int) error {
+	if !m.HasRankDirectory() {
+		return fmt.Errorf("rank・selectのディレクトリが無い: 先にBuildRankDirectoryを呼ぶべき")
+	}
+
+	if r < 0 || r >= m.rows {
+		return fmt.Errorf("0 <= row < %d であるべき: row = %d", m.rows, r)
+	}
+	return nil
+}
+
+// Rank1は、r行目のc列より前(c列を含まない)の1の数を定数時間で返す。0 <= c <= Cols であるべき。
+func (m *Matrix) Rank1(r, c int) (int, error) {
+	if err := m.validateRankDirectory(r); err != nil {
+		return 0, err
+	}
+
+	if c < 0 || c > m.cols {
+		return 0, fmt.Errorf("0 <= col <= %d であるべき: col = %d", m.cols, c)
+	}
+
+	rowStart := r * m.Stride() * 64
+	return m.ranks.rank(m.data, rowStart+c) - m.ranks.rank(m.data, rowStart), nil
+}
+
+// Select1は、r行目のk番目(0始まり)の1の列を返す。0 <= k < (r行目の1の数) であるべき。
+func (m *Matrix) Select1(r, k int) (int, error) {
+	if err := m.validateRankDirectory(r); err != nil {
+		return 0, err
+	}
+
+	rowStart := r * m.Stride() * 64
+	before := m.ranks.rank(m.data, rowStart)
+	ones := m.ranks.rank(m.data, rowStart+m.cols) - before
+	if k < 0 || k >= ones {
+		return 0, fmt.Errorf("0 <= k < %d であるべき: k = %d", ones, k)
+	}
+	return m.ranks.select1(m.data, before+k) - rowStart, nil
+}
@@ -0,0 +1,327 @@
+package bitsx_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestMatrixRank1AndSelect1(t *testing.T) {
+	rng := rand.New(rand.NewPCG(101, 102))
+
+	// 行がブロック(512ビット)の途中で始まる列数や、1行が複数のブロックに跨がる列数を含める
+	for _, cols := range []int{1, 64, 70, 600, 1500} {
+		for _, k := range []int{-3, 0, 3} {
+			m, err := bitsx.NewRandMatrix(5, cols, k, rng)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if err := m.BuildRankDirectory(); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+
+			for r := range 5 {
+				var positions []int
+				for c := 0; c <= cols; c++ {
+					got, err := m.Rank1(r, c)
+					if err != nil {
+						t.Fatalf("予期せぬエラー: %v", err)
+					}
+					if got != len(positions) {
+						t.Fatalf("cols = %d: Rank1(%d, %d)の不一致: got = %d, want = %d", cols, r, c, got, len(positions))
+					}
+					if c < cols && mustBit(t, m, r, c) == 1 {
+						positions = append(positions, c)
+					}
+				}
+
+				for i, pos := range positions {
+					got, err := m.Select1(r, i)
+					if err != nil {
+						t.Fatalf("予期せぬエラー: %v", err)
+					}
+					if got != pos {
+						t.Fatalf("cols = %d: Select1(%d, %d)の不一致: got = %d, want = %d", cols, r, i, got, pos)
+					}
+				}
+				if _, err := m.Select1(r, len(positions)); err == nil {
+					t.Fatalf("エラーを期待したが、nilが返された")
+				}
+			}
+		}
+	}
+
+	t.Run("異常_範囲外", func(t *testing.T) {
+		m, err := bitsx.NewOnesMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := m.BuildRankDirectory(); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.Rank1(2, 0); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.Rank1(0, 11); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.Select1(0, -1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestMatrixRankDirectoryLifecycle(t *testing.T) {
+	newMatrix := func(t *testing.T) *bitsx.Matrix {
+		t.Helper()
+		m, err := bitsx.NewRandMatrix(3, 100, 0, rand.New(rand.NewPCG(103, 104)))
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := m.BuildRankDirectory(); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		return m
+	}
+
+	t.Run("異常_ディレクトリが無い", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.Rank1(0, 0); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.Select1(0, 0); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+
+	mutations := map[string]func(m *bitsx.Matrix) error{
+		"Set":     func(m *bitsx.Matrix) error { return m.Set(1, 5) },
+		"Clear":   func(m *bitsx.Matrix) error { return m.Clear(1, 5) },
+		"Toggle":  func(m *bitsx.Matrix) error { return m.Toggle(1, 5) },
+		"SetWord": func(m *bitsx.Matrix) error { return m.SetWord(0, 1) },
+		"XorInPlace": func(m *bitsx.Matrix) error {
+			return m.XorInPlace(m.Clone())
+		},
+		"NotInPlace": func(m *bitsx.Matrix) error {
+			m.NotInPlace()
+			return nil
+		},
+	}
+	for name, mutate := range mutations {
+		t.Run("正常_"+name+"で破棄される", func(t *testing.T) {
+			m := newMatrix(t)
+			if err := mutate(m); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if m.HasRankDirectory() {
+				t.Fatalf("ディレクトリが破棄されていない")
+			}
+			if _, err := m.Rank1(0, 0); err == nil {
+				t.Fatalf("エラーを期待したが、nilが返された")
+			}
+		})
+	}
+
+	t.Run("正常_Cloneは引き継がず、元のディレクトリは残る", func(t *testing.T) {
+		m := newMatrix(t)
+		c := m.Clone()
+		if c.HasRankDirectory() {
+			t.Fatalf("複製にディレクトリが引き継がれた")
+		}
+		if !m.HasRankDirectory() {
+			t.Fatalf("元のディレクトリが破棄された")
+		}
+	})
+
+	t.Run("正常_バイナリ形式とJSONで保存される", func(t *testing.T) {
+		for _, hasDirectory := range []bool{true, false} {
+			m := newMatrix(t)
+			if !hasDirectory {
+				m.DropRankDirectory()
+			}
+
+			b, err := m.MarshalBinary()
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			// ディレクトリが無ければ、フラグの無いバージョン1で書き出す
+			wantVersion := uint32(1)
+			if hasDirectory {
+				wantVersion = 2
+			}
+			if v := binary.LittleEndian.Uint32(b[4:]); v != wantVersion {
+				t.Fatalf("バージョン = %d, want = %d", v, wantVersion)
+			}
+			var fromBinary bitsx.Matrix
+			if err := fromBinary.UnmarshalBinary(b); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+
+			j, err := json.Marshal(m)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			var fromJSON bitsx.Matrix
+			if err := json.Unmarshal(j, &fromJSON); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+
+			for _, got := range []*bitsx.Matrix{&fromBinary, &fromJSON} {
+				if !got.Equal(m) || got.HasRankDirectory() != hasDirectory {
+					t.Fatalf("hasDirectory = %v: 往復でディレクトリの有無か内容が変化した", hasDirectory)
+				}
+				if !hasDirectory {
+					continue
+				}
+				for c := 0; c <= 100; c++ {
+					want, _ := m.Rank1(2, c)
+					if r, err := got.Rank1(2, c); err != nil || r != want {
+						t.Fatalf("Rank1(2, %d)の不一致: got = %d, want = %d, err = %v", c, r, want, err)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("正常_RowViewとの間で互いの書き込みが破棄する", func(t *testing.T) {
+		m := newMatrix(t)
+		view, err := m.RowView(1, 3)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := view.BuildRankDirectory(); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		sibling, err := m.RowView(2, 3)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := sibling.BuildRankDirectory(); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		// ビューへの書き込みは、元の行列と、dataを共有する別のビューのディレクトリを無効にする
+		before := mustBit(t, m, 1, 5)
+		if err := view.Toggle(0, 5); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if m.HasRankDirectory() || sibling.HasRankDirectory() {
+			t.Fatalf("ビューへの書き込みで、元の行列または別のビューのディレクトリが破棄されていない")
+		}
+		if _, err := m.Rank1(1, 100); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if err := m.BuildRankDirectory(); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want := 0
+		for c := range 100 {
+			want += int(mustBit(t, m, 1, c))
+		}
+		if r, err := m.Rank1(1, 100); err != nil || r != want {
+			t.Fatalf("Rank1(1, 100) = %d, want = %d, err = %v", r, want, err)
+		}
+		if mustBit(t, m, 1, 5) == before {
+			t.Fatalf("ビューへの書き込みが元の行列に反映されていない")
+		}
+
+		// 元の行列への書き込みは、ビューのディレクトリを無効にする
+		if err := view.BuildRankDirectory(); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := m.SetWord(1*m.Stride(), 0); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if view.HasRankDirectory() {
+			t.Fatalf("元の行列への書き込みで、ビューのディレクトリが破棄されていない")
+		}
+		if _, err := view.Select1(0, 0); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+
+	t.Run("正常_TernaryMatrixの正規化で古いディレクトリが残らない", func(t *testing.T) {
+		sign, err := bitsx.NewOnesMatrix(1, 128)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := sign.BuildRankDirectory(); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		nonZero, err := bitsx.NewZerosMatrix(1, 128)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		for c := 0; c < 128; c += 2 {
+			if err := nonZero.Set(0, c); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+		}
+
+		tm, err := bitsx.NewTernaryMatrixFromPlanes(sign, nonZero)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if err := tm.Set(0, 2, 0); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got := tm.Sign()
+		if err := got.BuildRankDirectory(); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if r, err := got.Rank1(0, 128); err != nil || r != got.OnesCount() {
+			t.Fatalf("Rank1(0, 128) = %d, OnesCount = %d, err = %v", r, got.OnesCount(), err)
+		}
+		for k := range got.OnesCount() {
+			c, err := got.Select1(0, k)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if mustBit(t, got, 0, c) != 1 {
+				t.Fatalf("Select1(0, %d) = %d の位置が0", k, c)
+			}
+		}
+	})
+
+	t.Run("正常_gobで保存される", func(t *testing.T) {
+		m := newMatrix(t)
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		var got *bitsx.Matrix
+		if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !got.HasRankDirectory() {
+			t.Fatalf("ディレクトリが復元されていない")
+		}
+		for c := 0; c <= 100; c++ {
+			want, _ := m.Rank1(2, c)
+			if r, err := got.Rank1(2, c); err != nil || r != want {
+				t.Fatalf("Rank1(2, %d)の不一致: got = %d, want = %d, err = %v", c, r, want, err)
+			}
+		}
+
+		m.DropRankDirectory()
+		buf.Reset()
+		if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got = nil
+		if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got.HasRankDirectory() {
+			t.Fatalf("無いはずのディレクトリが復元された")
+		}
+	})
+}
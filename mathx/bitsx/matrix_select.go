@@ -0,0 +1,130 @@
+package bitsx
+
+import (
+	"fmt"
+)
+
+func validateRange(name string, start, end, size int) error {
+	if start < 0 || start >= end || end > size {
+		return fmt.Errorf("%sの範囲が不正: [%d, %d): 0 <= start < end <= %d であるべき", name, start, end, size)
+	}
+	return nil
+}
+
+// RowViewは [r0, r1) 行を、mとdataを共有する行列として返す。コピーは行わない。
+// 行は連続して並んでいる為、どの範囲でも共有できる。
+// ビューへの書き込みはmにも反映され、その逆も同様。
+func (m *Matrix) RowView(r0, r1 int) (*Matrix, error) {
+	if err := validateRange("行", r0, r1, m.rows); err != nil {
+		return nil, err
+	}
+
+	stride := m.Stride()
+	start := r0 * stride
+	end := r1 * stride
+	return &Matrix{
+		rows: r1 - r0,
+		cols: m.cols,
+		// 容量を切り詰め、ビューへのappendがm側の後続の行を上書きしないようにする
+		data: m.data[start:end:end],
+		// 書き込みの世代を共有し、どちらへの書き込みでも互いのrank・selectのディレクトリを無効にする
+		gen: m.gen,
+	}, nil
+}
+
+// SubMatrixは [r0, r1) 行 × [c0, c1) 列 を、新たな行列にコピーして返す。
+func (m *Matrix) SubMatrix(r0, r1, c0, c1 int) (*Matrix, error) {
+	if err := validateRange("行", r0, r1, m.rows); err != nil {
+		return nil, err
+	}
+
+	if err := validateRange("列", c0, c1, m.cols); err != nil {
+		return nil, err
+	}
+
+	sub, err := NewZerosMatrix(r1-r0, c1-c0)
+	if err != nil {
+		return nil, err
+	}
+
+	srcStride := m.Stride()
+	dstStride := sub.Stride()
+	for r := r0; r < r1; r++ {
+		srcRow := m.data[r*srcStride : (r+1)*srcStride]
+		dstRow := sub.data[(r-r0)*dstStride : (r-r0+1)*dstStride]
+		copyRowBits(dstRow, srcRow, c0)
+	}
+
+	sub.ApplyTailMask()
+	return sub, nil
+}
+
+// SelectRowsは、idxsの順に行を並べた新たな行列を返す。同じ行を複数回選んでもよい。
+func (m *Matrix) SelectRows(idxs []int) (*Matrix, error) {
+	dst, err := NewZerosMatrix(len(idxs), m.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := m.Stride()
+	for i, r := range idxs {
+		if r < 0 || r >= m.rows {
+			return nil, fmt.Errorf("0 <= row < %d であるべき: idxs[%d] = %d", m.rows, i, r)
+		}
+		copy(dst.data[i*stride:(i+1)*stride], m.data[r*stride:(r+1)*stride])
+	}
+	return dst, nil
+}
+
+// SelectColsは、idxsの順に列を並べた新たな行列を返す。同じ列を複数回選んでもよい。
+func (m *Matrix) SelectCols(idxs []int) (*Matrix, error) {
+	dst, err := NewZerosMatrix(m.rows, len(idxs))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, c := range idxs {
+		if c < 0 || c >= m.cols {
+			return nil, fmt.Errorf("0 <= col < %d であるべき: idxs[%d] = %d", m.cols, i, c)
+		}
+	}
+
+	srcStride := m.Stride()
+	dstStride := dst.Stride()
+	for r := range m.rows {
+		srcRow := m.data[r*srcStride : (r+1)*srcStride]
+		dstRow := dst.data[r*dstStride : (r+1)*dstStride]
+		for i, c := range idxs {
+			bit := (srcRow[c/64] >> uint(c%64)) & 1
+			dstRow[i/64] |= bit << uint(i%64)
+		}
+	}
+	return dst, nil
+}
+
+// srcRowのsrcStart列目以降を、dstRowの0列目からlen(dstRow)ワード分コピーする。
+// srcRowの範囲外は0として扱う。端数ビットのマスクは呼び出し側で行う。
+func copyRowBits(dstRow, srcRow []uint64, srcStart int) {
+	wordIdx := srcStart / 64
+	shift := uint(srcStart % 64)
+
+	// ワード境界に揃っている場合は、ワード単位でコピーできる
+	if shift == 0 {
+		n := copy(dstRow, srcRow[wordIdx:])
+		clear(dstRow[n:])
+		return
+	}
+
+	// 揃っていない場合は、隣接する2ワードをシフトして繋ぎ合わせる
+	for i := range dstRow {
+		w := wordIdx + i
+		var word uint64
+		if w < len(srcRow) {
+			word = srcRow[w] >> shift
+		}
+		if w+1 < len(srcRow) {
+			word |= srcRow[w+1] << (64 - shift)
+		}
+		dstRow[i] = word
+	}
+}
@@ -0,0 +1,169 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestMatrixSubMatrix(t *testing.T) {
+	rng := rand.New(rand.NewPCG(27, 28))
+	m, err := bitsx.NewRandMatrix(5, 200, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	// ワード境界に揃った範囲・揃っていない範囲・ワードを跨ぐ範囲
+	ranges := []struct{ r0, r1, c0, c1 int }{
+		{0, 5, 0, 200},
+		{1, 3, 64, 128},
+		{0, 1, 3, 4},
+		{2, 5, 60, 70},
+		{0, 5, 1, 200},
+		{4, 5, 130, 199},
+	}
+
+	for _, rg := range ranges {
+		sub, err := m.SubMatrix(rg.r0, rg.r1, rg.c0, rg.c1)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if sub.Rows() != rg.r1-rg.r0 || sub.Cols() != rg.c1-rg.c0 {
+			t.Fatalf("%v: 形状の不一致: got = (%d, %d)", rg, sub.Rows(), sub.Cols())
+		}
+		ones := 0
+		for r := range sub.Rows() {
+			for c := range sub.Cols() {
+				got := mustBit(t, sub, r, c)
+				if want := mustBit(t, m, rg.r0+r, rg.c0+c); got != want {
+					t.Fatalf("%v: (%d, %d)の値の不一致: got = %d, want = %d", rg, r, c, got, want)
+				}
+				ones += int(got)
+			}
+		}
+		// 端数ビットが0であれば、HammingDistanceは論理的なビットだけを数える
+		zeros, err := bitsx.NewZerosMatrix(sub.Rows(), sub.Cols())
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if d, _ := sub.HammingDistance(zeros); d != ones {
+			t.Fatalf("%v: 端数ビットが0に保たれていない", rg)
+		}
+	}
+
+	t.Run("異常_範囲外", func(t *testing.T) {
+		for _, rg := range []struct{ r0, r1, c0, c1 int }{
+			{-1, 2, 0, 10},
+			{0, 6, 0, 10},
+			{2, 2, 0, 10},
+			{0, 2, 10, 5},
+			{0, 2, 0, 201},
+		} {
+			if _, err := m.SubMatrix(rg.r0, rg.r1, rg.c0, rg.c1); err == nil {
+				t.Fatalf("%v: エラーを期待したが、nilが返された", rg)
+			}
+		}
+	})
+}
+
+func TestMatrixRowView(t *testing.T) {
+	m, err := bitsx.NewZerosMatrix(4, 70)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	view, err := m.RowView(1, 3)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if view.Rows() != 2 || view.Cols() != 70 {
+		t.Fatalf("形状の不一致: got = (%d, %d), want = (2, 70)", view.Rows(), view.Cols())
+	}
+
+	t.Run("正常_ビューへの書き込みが元の行列に反映される", func(t *testing.T) {
+		if err := view.Set(0, 69); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got := mustBit(t, m, 1, 69); got != 1 {
+			t.Errorf("値の不一致: got = %d, want = 1", got)
+		}
+	})
+
+	t.Run("正常_元の行列への書き込みがビューに反映される", func(t *testing.T) {
+		if err := m.Set(2, 5); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got := mustBit(t, view, 1, 5); got != 1 {
+			t.Errorf("値の不一致: got = %d, want = 1", got)
+		}
+	})
+
+	t.Run("異常_範囲外", func(t *testing.T) {
+		if _, err := m.RowView(3, 5); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.RowView(2, 2); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestMatrixSelectRowsAndCols(t *testing.T) {
+	rng := rand.New(rand.NewPCG(29, 30))
+	m, err := bitsx.NewRandMatrix(5, 130, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	t.Run("正常_SelectRows", func(t *testing.T) {
+		idxs := []int{4, 0, 0, 2}
+		got, err := m.SelectRows(idxs)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		for i, r := range idxs {
+			want, err := m.SubMatrix(r, r+1, 0, m.Cols())
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			row, err := got.SubMatrix(i, i+1, 0, got.Cols())
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if !row.Equal(want) {
+				t.Fatalf("%d行目が元の%d行目と一致しない", i, r)
+			}
+		}
+	})
+
+	t.Run("正常_SelectCols", func(t *testing.T) {
+		idxs := []int{129, 0, 64, 63, 63, 100}
+		got, err := m.SelectCols(idxs)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got.Rows() != 5 || got.Cols() != len(idxs) {
+			t.Fatalf("形状の不一致: got = (%d, %d)", got.Rows(), got.Cols())
+		}
+		for r := range m.Rows() {
+			for i, c := range idxs {
+				if mustBit(t, got, r, i) != mustBit(t, m, r, c) {
+					t.Fatalf("(%d, %d)が元の(%d, %d)と一致しない", r, i, r, c)
+				}
+			}
+		}
+	})
+
+	t.Run("異常_範囲外", func(t *testing.T) {
+		if _, err := m.SelectRows([]int{0, 5}); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.SelectCols([]int{-1}); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.SelectRows(nil); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package bitsx
+
+import (
+	"fmt"
+)
+
+// 列方向の向きは、Formatの表示に合わせる。0列目が左端で、右へ行くほど列の番号が大きくなる。
+// 行方向は、0行目が上端。いずれもワード単位でシフトし、端数ビットは0に保つ。
+
+// RotateColsは、各行をk列だけ右へ巡回シフトした行列を返す。c列目のビットは (c+k) mod Cols 列目へ移る。
+// kは負でもよく、RotateCols(-k)はRotateCols(k)の逆になる。
+func (m *Matrix) RotateCols(k int) (*Matrix, error) {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	rotated, err := NewZerosMatrix(m.rows, m.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := m.Stride()
+	tmp := make([]uint64, stride)
+	tailMask := m.TailMask()
+	for r := range m.rows {
+		srcRow := m.data[r*stride : (r+1)*stride]
+		dstRow := rotated.data[r*stride : (r+1)*stride]
+		rotateRowBits(dstRow, srcRow, tmp, m.cols, k, tailMask)
+	}
+	return rotated, nil
+}
+
+// ShiftColsRightは、各行をk列だけ右へシフトした行列を返す。c列目のビットはc+k列目へ移り、
+// Colsからあふれたビットは捨て、左端のk列は0で埋める。
+func (m *Matrix) ShiftColsRight(k int) (*Matrix, error) {
+	if err := m.validateShiftArgs(k); err != nil {
+		return nil, err
+	}
+
+	shifted, err := NewZerosMatrix(m.rows, m.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	if k >= m.cols {
+		return shifted, nil
+	}
+
+	stride := m.Stride()
+	tailMask := m.TailMask()
+	for r := range m.rows {
+		srcRow := m.data[r*stride : (r+1)*stride]
+		dstRow := shifted.data[r*stride : (r+1)*stride]
+		// 残る列を含むワードだけを渡せば範囲内に収まる
+		orRowBits(dstRow, srcRow[:(m.cols-k+63)/64], k)
+		dstRow[stride-1] &= tailMask
+	}
+	return shifted, nil
+}
+
+// ShiftColsLeftは、各行をk列だけ左へシフトした行列を返す。c列目のビットはc-k列目へ移り、
+// 0列目からあふれたビットは捨て、右端のk列は0で埋める。
+func (m *Matrix) ShiftColsLeft(k int) (*Matrix, error) {
+	if err := m.validateShiftArgs(k); err != nil {
+		return nil, err
+	}
+
+	shifted, err := NewZerosMatrix(m.rows, m.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	if k >= m.cols {
+		return shifted, nil
+	}
+
+	stride := m.Stride()
+	for r := range m.rows {
+		srcRow := m.data[r*stride : (r+1)*stride]
+		dstRow := shifted.data[r*stride : (r+1)*stride]
+		// 端数ビットが0なので、右端から入ってくるビットは0
+		copyRowBits(dstRow, srcRow, k)
+	}
+	return shifted, nil
+}
+
+// RotateRowsは、k行だけ下へ巡回シフトした行列を返す。r行目は (r+k) mod Rows 行目へ移る。
+// kは負でもよい。
+func (m *Matrix) RotateRows(k int) (*Matrix, error) {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	k %= m.rows
+	if k < 0 {
+		k += m.rows
+	}
+
+	// 行は連続したStrideワードなので、dataを2つに分けて入れ替えるだけでよい
+	split := (m.rows - k) * m.Stride()
+	rotated := &Matrix{rows: m.rows, cols: m.cols, data: make([]uint64, len(m.data)), gen: new(uint64)}
+	n := copy(rotated.data, m.data[split:])
+	copy(rotated.data[n:], m.data[:split])
+	return rotated, nil
+}
+
+// ShiftRowsDownは、k行だけ下へシフトした行列を返す。あふれた行は捨て、上端のk行は0で埋める。
+func (m *Matrix) ShiftRowsDown(k int) (*Matrix, error) {
+	if err := m.validateShiftArgs(k); err != nil {
+		return nil, err
+	}
+
+	shifted, err := NewZerosMatrix(m.rows, m.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	if k < m.rows {
+		stride := m.Stride()
+		copy(shifted.data[k*stride:], m.data[:(m.rows-k)*stride])
+	}
+	return shifted, nil
+}
+
+// ShiftRowsUpは、k行だけ上へシフトした行列を返す。あふれた行は捨て、下端のk行は0で埋める。
+func (m *Matrix) ShiftRowsUp(k int) (*Matrix, error) {
+	if err := m.validateShiftArgs(k); err != nil {
+		return nil, err
+	}
+
+	shifted, err := NewZerosMatrix(m.rows, m.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	if k < m.rows {
+		copy(shifted.data, m.data[k*m.Stride():])
+	}
+	return shifted, nil
+}
+
+func (m *Matrix) validateShiftArgs(k int) error {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return err
+	}
+
+	if k < 0 {
+		return fmt.Errorf("k >= 0 であるべき: k = %d", k)
+	}
+	return nil
+}
+
+// srcRowを、k列だけ右へ巡回シフトしてdstRowへ書き込む。
+// dstRowはゼロ埋めされている前提。tmpは作業領域で、dstRow・srcRowと同じ長さ。
+func rotateRowBits(dstRow, srcRow, tmp []uint64, cols, k int, tailMask uint64) {
+	k %= cols
+	if k < 0 {
+		k += cols
+	}
+
+	// 前半 (0..cols-k-1列目) を k列目以降へ移す。
+	// 前半を含むワードだけを渡せば範囲内に収まる。Colsを超えたビットは下で捨てる
+	orRowBits(dstRow, srcRow[:(cols-k+63)/64], k)
+	dstRow[len(dstRow)-1] &= tailMask
+
+	// 後半 (cols-k..cols-1列目) を先頭へ回り込ませる。端数ビットが0なので、tmpのk列目以降は0
+	if k > 0 {
+		copyRowBits(tmp, srcRow, cols-k)
+		for i, word := range tmp {
+			dstRow[i] |= word
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestMatrixShiftCols(t *testing.T) {
+	rng := rand.New(rand.NewPCG(53, 54))
+	for _, cols := range []int{1, 7, 64, 65, 100, 130, 192} {
+		m, err := bitsx.NewRandMatrix(3, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		for _, k := range []int{0, 1, 63, 64, 65, cols - 1, cols, cols + 1} {
+			right, err := m.ShiftColsRight(k)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			left, err := m.ShiftColsLeft(k)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			rotated, err := m.RotateCols(k)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+
+			wantRightOnes, wantLeftOnes := 0, 0
+			for r := range 3 {
+				for c := range cols {
+					var wantRight, wantLeft uint64
+					if c-k >= 0 {
+						wantRight = mustBit(t, m, r, c-k)
+					}
+					if c+k < cols {
+						wantLeft = mustBit(t, m, r, c+k)
+					}
+					if got := mustBit(t, right, r, c); got != wantRight {
+						t.Fatalf("cols = %d, k = %d: ShiftColsRightの(%d, %d)の値の不一致: got = %d, want = %d", cols, k, r, c, got, wantRight)
+					}
+					if got := mustBit(t, left, r, c); got != wantLeft {
+						t.Fatalf("cols = %d, k = %d: ShiftColsLeftの(%d, %d)の値の不一致: got = %d, want = %d", cols, k, r, c, got, wantLeft)
+					}
+					if got, want := mustBit(t, rotated, r, c), mustBit(t, m, r, ((c-k)%cols+cols)%cols); got != want {
+						t.Fatalf("cols = %d, k = %d: RotateColsの(%d, %d)の値の不一致: got = %d, want = %d", cols, k, r, c, got, want)
+					}
+					wantRightOnes += int(wantRight)
+					wantLeftOnes += int(wantLeft)
+				}
+			}
+
+			// 端数ビットが0であれば、OnesCountは論理的なビットだけを数える
+			if right.OnesCount() != wantRightOnes || left.OnesCount() != wantLeftOnes {
+				t.Fatalf("cols = %d, k = %d: 端数ビットが0に保たれていない", cols, k)
+			}
+		}
+	}
+
+	t.Run("異常_負のk", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.ShiftColsLeft(-1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.ShiftColsRight(-1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestMatrixShiftRows(t *testing.T) {
+	m, err := bitsx.ParseMatrix("[100 010 001 111]")
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		shift func() (*bitsx.Matrix, error)
+		want  string
+	}{
+		{"正常_RotateRows", func() (*bitsx.Matrix, error) { return m.RotateRows(1) }, "[111 100 010 001]"},
+		{"正常_RotateRows_負", func() (*bitsx.Matrix, error) { return m.RotateRows(-5) }, "[010 001 111 100]"},
+		{"正常_ShiftRowsDown", func() (*bitsx.Matrix, error) { return m.ShiftRowsDown(1) }, "[000 100 010 001]"},
+		{"正常_ShiftRowsUp", func() (*bitsx.Matrix, error) { return m.ShiftRowsUp(3) }, "[111 000 000 000]"},
+		{"正常_ShiftRowsUp_全て捨てる", func() (*bitsx.Matrix, error) { return m.ShiftRowsUp(4) }, "[000 000 000 000]"},
+		{"正常_ShiftRowsDown_0", func() (*bitsx.Matrix, error) { return m.ShiftRowsDown(0) }, "[100 010 001 111]"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.shift()
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("値の不一致: got = %v, want = %s", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("異常_負のk", func(t *testing.T) {
+		if _, err := m.ShiftRowsDown(-1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.ShiftRowsUp(-1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package bitsx
+
+import (
+	"fmt"
+)
+
+// HStackは、行数が等しい行列を左から順に横へ連結する。
+// 各行列の行を、連結後の列の位置へワード単位でシフトしながら詰め直す。
+func HStack(ms ...*Matrix) (*Matrix, error) {
+	if len(ms) == 0 {
+		return nil, fmt.Errorf("len(ms) > 0 であるべき")
+	}
+
+	rows := ms[0].rows
+	cols := 0
+	for i, m := range ms {
+		if m.rows != rows {
+			return nil, fmt.Errorf("行数の不一致: ms[0].Rows = %d, ms[%d].Rows = %d", rows, i, m.rows)
+		}
+		cols += m.cols
+	}
+
+	dst, err := NewZerosMatrix(rows, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	dstStride := dst.Stride()
+	colOffset := 0
+	for _, m := range ms {
+		srcStride := m.Stride()
+		for r := range rows {
+			srcRow := m.data[r*srcStride : (r+1)*srcStride]
+			dstRow := dst.data[r*dstStride : (r+1)*dstStride]
+			orRowBits(dstRow, srcRow, colOffset)
+		}
+		colOffset += m.cols
+	}
+	return dst, nil
+}
+
+// VStackは、列数が等しい行列を上から順に縦へ連結する。
+func VStack(ms ...*Matrix) (*Matrix, error) {
+	if len(ms) == 0 {
+		return nil, fmt.Errorf("len(ms) > 0 であるべき")
+	}
+
+	cols := ms[0].cols
+	rows := 0
+	for i, m := range ms {
+		if m.cols != cols {
+			return nil, fmt.Errorf("列数の不一致: ms[0].Cols = %d, ms[%d].Cols = %d", cols, i, m.cols)
+		}
+		rows += m.rows
+	}
+
+	dst, err := NewZerosMatrix(rows, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	// 列数が等しければStrideも等しい為、dataをそのまま繋げられる
+	offset := 0
+	for _, m := range ms {
+		offset += copy(dst.data[offset:], m.data)
+	}
+	return dst, nil
+}
+
+// srcRowを、dstRowのdstStart列目から書き込む(OR)。
+// srcRowの端数ビットが0である事と、書き込み先が0である事を前提とする。
+func orRowBits(dstRow, srcRow []uint64, dstStart int) {
+	wordIdx := dstStart / 64
+	shift := uint(dstStart % 64)
+
+	if shift == 0 {
+		for i, word := range srcRow {
+			dstRow[wordIdx+i] |= word
+		}
+		return
+	}
+
+	for i, word := range srcRow {
+		w := wordIdx + i
+		dstRow[w] |= word << shift
+		// 上位からあふれたビットは次のワードへ。
+		// srcRowの端数ビットが0なので、dstRowの範囲外へあふれるビットは常に0
+		if w+1 < len(dstRow) {
+			dstRow[w+1] |= word >> (64 - shift)
+		}
+	}
+}
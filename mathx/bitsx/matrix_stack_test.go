@@ -0,0 +1,124 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestHStack(t *testing.T) {
+	rng := rand.New(rand.NewPCG(31, 32))
+
+	// ワード境界に揃う幅・揃わない幅を混ぜる
+	widths := []int{3, 64, 70, 1, 130, 61}
+	ms := make(bitsx.Matrices, len(widths))
+	ones := 0
+	for i, w := range widths {
+		m, err := bitsx.NewRandMatrix(4, w, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		ms[i] = m
+		ones += m.OnesCount()
+	}
+
+	got, err := ms.HStack()
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	if got.Rows() != 4 || got.Cols() != total {
+		t.Fatalf("形状の不一致: got = (%d, %d), want = (4, %d)", got.Rows(), got.Cols(), total)
+	}
+
+	c0 := 0
+	for i, m := range ms {
+		part, err := got.SubMatrix(0, 4, c0, c0+m.Cols())
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !part.Equal(m) {
+			t.Fatalf("ms[%d]の部分が一致しない", i)
+		}
+		c0 += m.Cols()
+	}
+
+	// 端数ビットが0であれば、全て0の行列とのハミング距離は1の個数と一致する
+	zeros, err := bitsx.NewZerosMatrix(got.Rows(), got.Cols())
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if d, _ := got.HammingDistance(zeros); d != ones {
+		t.Fatalf("端数ビットが0に保たれていない: got = %d, want = %d", d, ones)
+	}
+
+	t.Run("異常_行数の不一致", func(t *testing.T) {
+		a, err := bitsx.NewZerosMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		b, err := bitsx.NewZerosMatrix(3, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := bitsx.HStack(a, b); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := bitsx.HStack(); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestVStack(t *testing.T) {
+	rng := rand.New(rand.NewPCG(33, 34))
+
+	heights := []int{1, 3, 2}
+	ms := make(bitsx.Matrices, len(heights))
+	for i, h := range heights {
+		m, err := bitsx.NewRandMatrix(h, 70, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		ms[i] = m
+	}
+
+	got, err := ms.VStack()
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if got.Rows() != 6 || got.Cols() != 70 {
+		t.Fatalf("形状の不一致: got = (%d, %d), want = (6, 70)", got.Rows(), got.Cols())
+	}
+
+	r0 := 0
+	for i, m := range ms {
+		part, err := got.RowView(r0, r0+m.Rows())
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !part.Equal(m) {
+			t.Fatalf("ms[%d]の部分が一致しない", i)
+		}
+		r0 += m.Rows()
+	}
+
+	t.Run("異常_列数の不一致", func(t *testing.T) {
+		a, err := bitsx.NewZerosMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		b, err := bitsx.NewZerosMatrix(2, 11)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := bitsx.VStack(a, b); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/gob"
 	"math"
 	"math/rand/v2"
+	"slices"
 	"testing"
 
 	"github.com/sw965/omw/mathx/bitsx"
@@ -289,6 +290,15 @@ func TestMatricesValidation(t *testing.T) {
 		}
 	})
 
+	t.Run("異常_NewETFMatricesParallelのcandidates・pが1未満", func(t *testing.T) {
+		if _, err := bitsx.NewETFMatricesParallel(3, 4, 8, 10, 0, 1, rng); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := bitsx.NewETFMatricesParallel(3, 4, 8, 10, 1, 0, rng); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+
 	t.Run("正常_生成された行列の個数と形状", func(t *testing.T) {
 		ms, err := bitsx.NewETFMatrices(3, 2, 70, 50, rng)
 		if err != nil {
@@ -305,6 +315,154 @@ func TestMatricesValidation(t *testing.T) {
 	})
 }
 
+// 最初の実装と同じ手順で、float32のまま距離の平均と分散を求める。
+func referenceETFCost(t *testing.T, ms bitsx.Matrices) float32 {
+	t.Helper()
+	var distances []float32
+	sum := float32(0.0)
+	for i := range ms {
+		for j := i + 1; j < len(ms); j++ {
+			distance, err := ms[i].HammingDistance(ms[j])
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			d := float32(distance)
+			distances = append(distances, d)
+			sum += d
+		}
+	}
+
+	dnf := float32(len(distances))
+	mean := sum / dnf
+	variance := float32(0.0)
+	for _, d := range distances {
+		deviation := d - mean
+		variance += deviation * deviation
+	}
+	variance /= dnf
+	return -sum + variance
+}
+
+// 丸めの差は一部の入力でしか現れないので、多くのシードで確かめる。
+func TestETFCostMatchesReference(t *testing.T) {
+	for _, tc := range []struct {
+		n, rows, cols int
+	}{{2, 1, 1}, {3, 2, 70}, {5, 1, 30}, {8, 2, 100}, {20, 4, 1000}} {
+		for seed := range 200 {
+			rng := rand.New(rand.NewPCG(uint64(seed), 1))
+			ms := make(bitsx.Matrices, tc.n)
+			for i := range ms {
+				m, err := bitsx.NewRandMatrix(tc.rows, tc.cols, 0, rng)
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				ms[i] = m
+			}
+			got, err := ms.ETFCost()
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if want := referenceETFCost(t, ms); got != want {
+				t.Fatalf("n = %d, cols = %d, seed = %d: 値の不一致: got = %v, want = %v", tc.n, tc.cols, seed, got, want)
+			}
+		}
+	}
+
+	t.Run("正常_NewETFMatricesは1ビットずつ数え直す探索と同じ", func(t *testing.T) {
+		for seed := range 10 {
+			assertETFMatricesMatchReference(t, 7, 1, 30, 300, uint64(seed))
+		}
+	})
+}
+
+// 最初の実装と同じく、1ビットを反転する度にreferenceETFCostを数え直して探索した結果と比べる。
+func assertETFMatricesMatchReference(t *testing.T, n, rows, cols, iters int, seed uint64) {
+	t.Helper()
+	got, err := bitsx.NewETFMatrices(n, rows, cols, iters, rand.New(rand.NewPCG(seed, 2)))
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	rng := rand.New(rand.NewPCG(seed, 2))
+	want := make(bitsx.Matrices, n)
+	for i := range want {
+		m, err := bitsx.NewRandMatrix(rows, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want[i] = m
+	}
+	current := referenceETFCost(t, want)
+	for range iters {
+		i, r, c := rng.IntN(n), rng.IntN(rows), rng.IntN(cols)
+		if err := want[i].Toggle(r, c); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if cost := referenceETFCost(t, want); cost < current {
+			current = cost
+		} else if err := want[i].Toggle(r, c); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+	}
+
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Fatalf("seed = %d: ms[%d]が一致しない", seed, i)
+		}
+	}
+}
+
+func TestNewETFMatricesParallel(t *testing.T) {
+	newMatrices := func(candidates, p int) bitsx.Matrices {
+		ms, err := bitsx.NewETFMatricesParallel(8, 2, 100, 200, candidates, p, rand.New(rand.NewPCG(79, 80)))
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		return ms
+	}
+
+	t.Run("正常_結果はpに依らない", func(t *testing.T) {
+		want := newMatrices(16, 1)
+		got := newMatrices(16, 4)
+		for i := range want {
+			if !got[i].Equal(want[i]) {
+				t.Fatalf("ms[%d]が一致しない", i)
+			}
+		}
+	})
+
+	t.Run("正常_candidatesが1ならNewETFMatricesと同じ", func(t *testing.T) {
+		want, err := bitsx.NewETFMatrices(8, 2, 100, 200, rand.New(rand.NewPCG(79, 80)))
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got := newMatrices(1, 3)
+		for i := range want {
+			if !got[i].Equal(want[i]) {
+				t.Fatalf("ms[%d]が一致しない", i)
+			}
+		}
+	})
+
+	t.Run("正常_反復でコストが下がる", func(t *testing.T) {
+		initial, err := bitsx.NewETFMatricesParallel(8, 2, 100, 0, 16, 4, rand.New(rand.NewPCG(79, 80)))
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		before, err := initial.ETFCost()
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		after, err := newMatrices(16, 4).ETFCost()
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if after >= before {
+			t.Errorf("コストが下がっていない: before = %v, after = %v", before, after)
+		}
+	})
+}
+
 func TestMatrixWord(t *testing.T) {
 	// cols=70 なら Stride()=2 なので、rows=2の内部データ長は4
 	m, err := bitsx.NewOnesMatrix(2, 70)
@@ -450,6 +608,106 @@ func TestMatrixGobRoundTrip(t *testing.T) {
 	}
 }
 
+func TestMatrixDotInto(t *testing.T) {
+	rng := rand.New(rand.NewPCG(17, 18))
+	left, err := bitsx.NewRandMatrix(5, 130, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	right, err := bitsx.NewRandMatrix(9, 130, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	nonZero, err := bitsx.NewRandMatrix(9, 130, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	wantDot, err := left.Dot(right)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	wantTernary, err := left.DotTernary(right, nonZero)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	t.Run("正常_Intoは同じ結果を書き込む", func(t *testing.T) {
+		dst := make([]int, len(wantDot))
+		if err := left.DotInto(dst, right); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !slices.Equal(dst, wantDot) {
+			t.Errorf("DotIntoの不一致: got = %v, want = %v", dst, wantDot)
+		}
+
+		if err := left.DotTernaryInto(dst, right, nonZero); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !slices.Equal(dst, wantTernary) {
+			t.Errorf("DotTernaryIntoの不一致: got = %v, want = %v", dst, wantTernary)
+		}
+	})
+
+	t.Run("正常_int32とint16の結果", func(t *testing.T) {
+		got32, err := left.DotInt32(right)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got16, err := left.DotInt16(right)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		for i, want := range wantDot {
+			if int(got32[i]) != want || int(got16[i]) != want {
+				t.Fatalf("Dot[%d]の不一致: int32 = %d, int16 = %d, want = %d", i, got32[i], got16[i], want)
+			}
+		}
+
+		ternary32 := make([]int32, len(wantTernary))
+		if err := left.DotTernaryInt32Into(ternary32, right, nonZero); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		ternary16, err := left.DotTernaryInt16(right, nonZero)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		for i, want := range wantTernary {
+			if int(ternary32[i]) != want || int(ternary16[i]) != want {
+				t.Fatalf("DotTernary[%d]の不一致: int32 = %d, int16 = %d, want = %d", i, ternary32[i], ternary16[i], want)
+			}
+		}
+	})
+
+	t.Run("異常_dstの長さが不一致", func(t *testing.T) {
+		if err := left.DotInto(make([]int, len(wantDot)-1), right); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if err := left.DotInt16Into(make([]int16, len(wantDot)+1), right); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if err := left.DotTernaryInto(nil, right, nonZero); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+
+	t.Run("異常_列数がint16に収まらない", func(t *testing.T) {
+		m, err := bitsx.NewZerosMatrix(1, math.MaxInt16+1)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := m.DotInt16(m); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.DotTernaryInt16(m, m); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := m.DotInt32(m); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+	})
+}
+
 const (
 	benchTransposeRows = 768
 	benchTransposeCols = 768
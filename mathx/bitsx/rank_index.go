@@ -0,0 +1,122 @@
+package bitsx
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	// rankIndexのブロックのワード数。512ビットごと
+	rankBlockWords = 8
+
+	// rankIndexが位置を持つ1の間隔。selectの二分探索の範囲を絞る
+	selectSampleRate = 512
+)
+
+// rankIndexは、ワード列に対するrank・selectの為の索引(rank9)。
+// 512ビットのブロックごとに、ブロックより前の1の数と、ブロック内の各ワードより前の1の数(9ビット x 7個)を持つ。
+// rankは表を2回引き、1ワードを数えるだけで済む。追加のメモリは元のビット数の1/4と、selectの標本。
+type rankIndex struct {
+	// blockRanks[b] は、b番目のブロックより前の1の数。末尾に全体の1の数を持つ
+	blockRanks []int
+
+	// wordRanks[b] の 9*(j-1) ビット目からの9ビットは、b番目のブロック内でj番目のワードより前の1の数 (1 <= j < 8)
+	wordRanks []uint64
+
+	// selectSamples[j] は、(j*selectSampleRate)番目の1を含むブロックの番号
+	selectSamples []int
+
+	// 作った時のMatrix.gen
+	gen uint64
+}
+
+func newRankIndex(words []uint64) *rankIndex {
+	numBlocks := (len(words) + rankBlockWords - 1) / rankBlockWords
+	idx := &rankIndex{
+		blockRanks: make([]int, numBlocks+1),
+		wordRanks:  make([]uint64, numBlocks),
+	}
+
+	ones := 0
+	for b := range numBlocks {
+		idx.blockRanks[b] = ones
+		inBlock := 0
+		for j, word := range words[b*rankBlockWords : min((b+1)*rankBlockWords, len(words))] {
+			if j > 0 {
+				idx.wordRanks[b] |= uint64(inBlock) << uint(9*(j-1))
+			}
+
+			c := bits.OnesCount64(word)
+			// このワードで次の標本の1を跨ぐなら、その1を含むブロックを記録する
+			for len(idx.selectSamples)*selectSampleRate < ones+inBlock+c {
+				idx.selectSamples = append(idx.selectSamples, b)
+			}
+			inBlock += c
+		}
+		ones += inBlock
+	}
+	idx.blockRanks[numBlocks] = ones
+	return idx
+}
+
+func (idx *rankIndex) ones() int {
+	return idx.blockRanks[len(idx.blockRanks)-1]
+}
+
+// b番目のブロック内で、j番目のワードより前の1の数。
+func (idx *rankIndex) wordRank(b, j int) int {
+	if j == 0 {
+		return 0
+	}
+	return int((idx.wordRanks[b] >> uint(9*(j-1))) & 0x1FF)
+}
+
+// rankは、先頭からi未満の位置の1の数を返す。0 <= i <= 64*len(words) である前提。
+func (idx *rankIndex) rank(words []uint64, i int) int {
+	w := i / 64
+	if w == len(words) {
+		return idx.ones()
+	}
+
+	b := w / rankBlockWords
+	count := idx.blockRanks[b] + idx.wordRank(b, w%rankBlockWords)
+	if shift := uint(i % 64); shift > 0 {
+		count += bits.OnesCount64(words[w] & ((uint64(1) << shift) - 1))
+	}
+	return count
+}
+
+// select1は、k番目(0始まり)の1の位置を返す。0 <= k < ones() である前提。
+func (idx *rankIndex) select1(words []uint64, k int) int {
+	// 標本でブロックの範囲を絞ってから、blockRanksを二分探索する
+	j := k / selectSampleRate
+	lo := idx.selectSamples[j]
+	hi := len(idx.blockRanks) - 1
+	if j+1 < len(idx.selectSamples) {
+		hi = idx.selectSamples[j+1] + 1
+	}
+	// blockRanks[b+1] > k となる最初のブロックb
+	b := lo + sort.Search(hi-lo, func(i int) bool {
+		return idx.blockRanks[lo+i+1] > k
+	})
+
+	// ブロック内の何番目のワードかを、wordRanksから求める
+	rest := k - idx.blockRanks[b]
+	w := b * rankBlockWords
+	for j := rankBlockWords - 1; j > 0; j-- {
+		if w+j < len(words) && idx.wordRank(b, j) <= rest {
+			w += j
+			rest -= idx.wordRank(b, j)
+			break
+		}
+	}
+	return w*64 + selectInWord(words[w], rest)
+}
+
+// wordのk番目(0始まり)の1の位置を返す。
+func selectInWord(word uint64, k int) int {
+	for range k {
+		word = ClearLowest(word)
+	}
+	return bits.TrailingZeros64(word)
+}
@@ -0,0 +1,279 @@
+package bitsx
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math/bits"
+	"slices"
+
+	"github.com/sw965/omw/mathx"
+)
+
+// SparseMatrixは、1のビットの列番号を行ごとに持つ疎な行列(CSR形式)。
+// r行目の1の列番号は colIdxs[rowPtrs[r]:rowPtrs[r+1]] で、昇順に並ぶ。
+// 1の割合が小さい行列では、Matrixよりメモリが少なく、DotとHammingDistanceも1の個数に比例する時間で済む。
+type SparseMatrix struct {
+	rows    int
+	cols    int
+	rowPtrs []int
+	colIdxs []int
+}
+
+func NewZerosSparseMatrix(rows, cols int) (*SparseMatrix, error) {
+	if rows <= 0 {
+		return nil, fmt.Errorf("rows > 0 であるべき: rows = %d", rows)
+	}
+
+	if cols <= 0 {
+		return nil, fmt.Errorf("cols > 0 であるべき: cols = %d", cols)
+	}
+	return &SparseMatrix{rows: rows, cols: cols, rowPtrs: make([]int, rows+1)}, nil
+}
+
+func NewSparseMatrixFromDense(m *Matrix) (*SparseMatrix, error) {
+	if err := m.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	s := &SparseMatrix{
+		rows:    m.rows,
+		cols:    m.cols,
+		rowPtrs: make([]int, m.rows+1),
+		colIdxs: make([]int, 0, m.OnesCount()),
+	}
+
+	stride := m.Stride()
+	for r := range m.rows {
+		for i, word := range m.data[r*stride : (r+1)*stride] {
+			for ; word != 0; word = ClearLowest(word) {
+				s.colIdxs = append(s.colIdxs, i*64+bits.TrailingZeros64(word))
+			}
+		}
+		s.rowPtrs[r+1] = len(s.colIdxs)
+	}
+	return s, nil
+}
+
+func (s *SparseMatrix) ToDense() (*Matrix, error) {
+	m, err := NewZerosMatrix(s.rows, s.cols)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := m.Stride()
+	for r := range s.rows {
+		row := m.data[r*stride : (r+1)*stride]
+		for _, c := range s.rowCols(r) {
+			row[c/64] |= 1 << uint(c%64)
+		}
+	}
+	return m, nil
+}
+
+func (s *SparseMatrix) Rows() int {
+	return s.rows
+}
+
+func (s *SparseMatrix) Cols() int {
+	return s.cols
+}
+
+// OnesCountは1のビットの数(非零要素数)を返す。
+func (s *SparseMatrix) OnesCount() int {
+	return len(s.colIdxs)
+}
+
+func (s *SparseMatrix) Clone() *SparseMatrix {
+	return &SparseMatrix{
+		rows:    s.rows,
+		cols:    s.cols,
+		rowPtrs: slices.Clone(s.rowPtrs),
+		colIdxs: slices.Clone(s.colIdxs),
+	}
+}
+
+func (s *SparseMatrix) Equal(other *SparseMatrix) bool {
+	return s.rows == other.rows && s.cols == other.cols &&
+		slices.Equal(s.rowPtrs, other.rowPtrs) && slices.Equal(s.colIdxs, other.colIdxs)
+}
+
+func (s *SparseMatrix) rowCols(r int) []int {
+	return s.colIdxs[s.rowPtrs[r]:s.rowPtrs[r+1]]
+}
+
+// (r, c)が範囲内であれば、colIdxsの中で列番号cが入るべき位置と、既に1であるかを返す。
+func (s *SparseMatrix) search(r, c int) (int, bool, error) {
+	if r < 0 || r >= s.rows {
+		return 0, false, fmt.Errorf("0 <= row < %d であるべき: row = %d", s.rows, r)
+	}
+
+	if c < 0 || c >= s.cols {
+		return 0, false, fmt.Errorf("0 <= col < %d であるべき: col = %d", s.cols, c)
+	}
+
+	i, found := slices.BinarySearch(s.rowCols(r), c)
+	return s.rowPtrs[r] + i, found, nil
+}
+
+func (s *SparseMatrix) Bit(r, c int) (uint64, error) {
+	_, found, err := s.search(r, c)
+	if err != nil {
+		return 0, err
+	}
+
+	if found {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Setは(r, c)を1にする。後続の行の要素をずらす為、O(OnesCount())かかる。
+func (s *SparseMatrix) Set(r, c int) error {
+	i, found, err := s.search(r, c)
+	if err != nil || found {
+		return err
+	}
+
+	s.colIdxs = slices.Insert(s.colIdxs, i, c)
+	for j := r + 1; j <= s.rows; j++ {
+		s.rowPtrs[j]++
+	}
+	return nil
+}
+
+// Clearは(r, c)を0にする。後続の行の要素をずらす為、O(OnesCount())かかる。
+func (s *SparseMatrix) Clear(r, c int) error {
+	i, found, err := s.search(r, c)
+	if err != nil || !found {
+		return err
+	}
+
+	s.colIdxs = slices.Delete(s.colIdxs, i, i+1)
+	for j := r + 1; j <= s.rows; j++ {
+		s.rowPtrs[j]--
+	}
+	return nil
+}
+
+// 疎な行と密な行の、共通する1の数。
+func sparseDenseOverlap(sparseRow []int, denseRow []uint64) int {
+	count := 0
+	for _, c := range sparseRow {
+		count += int((denseRow[c/64] >> uint(c%64)) & 1)
+	}
+	return count
+}
+
+// Dotは、Matrix.Dotと同じく、sのr行目とotherのc行目で一致するビットの数を[r*other.Rows()+c]に返す。
+// 距離 = |sの行| + |otherの行| - 2*(共通する1の数) から求める為、sの1の個数に比例する時間で済む。
+func (s *SparseMatrix) Dot(other *Matrix) ([]int, error) {
+	if s.cols != other.cols {
+		return nil, fmt.Errorf("列数が不一致: s.Cols = %d, other.Cols = %d", s.cols, other.cols)
+	}
+
+	if err := other.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	resultsLen, ok := mathx.MulOverflowChecked(s.rows, other.rows)
+	if !ok {
+		return nil, fmt.Errorf("結果配列が大きすぎる: leftRows = %d, rightRows = %d", s.rows, other.rows)
+	}
+
+	otherOnes := other.RowOnesCount()
+	stride := other.Stride()
+	results := make([]int, resultsLen)
+	for r := range s.rows {
+		sparseRow := s.rowCols(r)
+		resultsRow := results[r*other.rows : (r+1)*other.rows]
+		for c := range other.rows {
+			overlap := sparseDenseOverlap(sparseRow, other.data[c*stride:(c+1)*stride])
+			distance := len(sparseRow) + otherOnes[c] - 2*overlap
+			resultsRow[c] = s.cols - distance
+		}
+	}
+	return results, nil
+}
+
+// HammingDistanceは、Matrix.HammingDistanceと同じく、形状が等しいotherとの異なるビットの数を返す。
+func (s *SparseMatrix) HammingDistance(other *Matrix) (int, error) {
+	if s.rows != other.rows || s.cols != other.cols {
+		return 0, fmt.Errorf("形状の不一致: (%d x %d) vs (%d x %d)", s.rows, s.cols, other.rows, other.cols)
+	}
+
+	if err := other.validateDotAVX512Family(); err != nil {
+		return 0, err
+	}
+
+	overlap := 0
+	stride := other.Stride()
+	for r := range s.rows {
+		overlap += sparseDenseOverlap(s.rowCols(r), other.data[r*stride:(r+1)*stride])
+	}
+	return len(s.colIdxs) + other.OnesCount() - 2*overlap, nil
+}
+
+type gobEncodedSparseMatrix struct {
+	Rows    int
+	Cols    int
+	RowPtrs []int
+	ColIdxs []int
+}
+
+func (s *SparseMatrix) GobEncode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	payload := gobEncodedSparseMatrix{Rows: s.rows, Cols: s.cols, RowPtrs: s.rowPtrs, ColIdxs: s.colIdxs}
+	if err := gob.NewEncoder(buf).Encode(payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *SparseMatrix) GobDecode(b []byte) error {
+	var payload gobEncodedSparseMatrix
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&payload); err != nil {
+		return err
+	}
+
+	decoded := &SparseMatrix{rows: payload.Rows, cols: payload.Cols, rowPtrs: payload.RowPtrs, colIdxs: payload.ColIdxs}
+	if err := decoded.validate(); err != nil {
+		return fmt.Errorf("デコードされたSparseMatrixが不正: %w", err)
+	}
+
+	*s = *decoded
+	return nil
+}
+
+func (s *SparseMatrix) validate() error {
+	if s.rows <= 0 || s.cols <= 0 {
+		return fmt.Errorf("形状が不正: (%d x %d): Rows > 0 かつ Cols > 0 であるべき", s.rows, s.cols)
+	}
+
+	if len(s.rowPtrs) != s.rows+1 {
+		return fmt.Errorf("len(rowPtrs) = Rows + 1 であるべき: len(rowPtrs) = %d, Rows = %d", len(s.rowPtrs), s.rows)
+	}
+
+	if s.rowPtrs[0] != 0 || s.rowPtrs[s.rows] != len(s.colIdxs) {
+		return fmt.Errorf("rowPtrsの両端が不正: rowPtrs[0] = %d, rowPtrs[Rows] = %d, len(colIdxs) = %d",
+			s.rowPtrs[0], s.rowPtrs[s.rows], len(s.colIdxs))
+	}
+
+	// 列番号を見る前に、全ての範囲がcolIdxsに収まる事を確かめる
+	for r := range s.rows {
+		if s.rowPtrs[r] > s.rowPtrs[r+1] {
+			return fmt.Errorf("rowPtrsが減少している: rowPtrs[%d] = %d, rowPtrs[%d] = %d", r, s.rowPtrs[r], r+1, s.rowPtrs[r+1])
+		}
+	}
+
+	for r := range s.rows {
+		prev := -1
+		for _, c := range s.rowCols(r) {
+			if c <= prev || c >= s.cols {
+				return fmt.Errorf("%d行目の列番号が不正: 0 <= col < %d で狭義単調増加であるべき: col = %d", r, s.cols, c)
+			}
+			prev = c
+		}
+	}
+	return nil
+}
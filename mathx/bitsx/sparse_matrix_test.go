@@ -0,0 +1,224 @@
+package bitsx_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func TestSparseMatrixDenseInterop(t *testing.T) {
+	rng := rand.New(rand.NewPCG(81, 82))
+	for _, cols := range []int{1, 64, 70, 300} {
+		dense, err := bitsx.NewRandMatrix(6, cols, -4, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		s, err := bitsx.NewSparseMatrixFromDense(dense)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if s.OnesCount() != dense.OnesCount() {
+			t.Fatalf("cols = %d: OnesCountの不一致: got = %d, want = %d", cols, s.OnesCount(), dense.OnesCount())
+		}
+		for r := range 6 {
+			for c := range cols {
+				got, err := s.Bit(r, c)
+				if err != nil {
+					t.Fatalf("予期せぬエラー: %v", err)
+				}
+				if want := mustBit(t, dense, r, c); got != want {
+					t.Fatalf("cols = %d: (%d, %d)の値の不一致: got = %d, want = %d", cols, r, c, got, want)
+				}
+			}
+		}
+
+		back, err := s.ToDense()
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !back.Equal(dense) {
+			t.Fatalf("cols = %d: ToDenseで元に戻らない", cols)
+		}
+	}
+}
+
+func TestSparseMatrixSetAndClear(t *testing.T) {
+	rng := rand.New(rand.NewPCG(83, 84))
+	s, err := bitsx.NewZerosSparseMatrix(4, 100)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	dense, err := bitsx.NewZerosMatrix(4, 100)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	// 同じ操作を密な行列にも行い、常に一致するかを確かめる
+	for range 500 {
+		r, c := rng.IntN(4), rng.IntN(100)
+		if rng.IntN(3) == 0 {
+			if err := s.Clear(r, c); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if err := dense.Clear(r, c); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+		} else {
+			if err := s.Set(r, c); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if err := dense.Set(r, c); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+		}
+	}
+
+	got, err := s.ToDense()
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if !got.Equal(dense) {
+		t.Fatalf("密な行列と一致しない")
+	}
+	fromDense, err := bitsx.NewSparseMatrixFromDense(dense)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if !s.Equal(fromDense) {
+		t.Fatalf("内部表現が正規化されていない")
+	}
+
+	t.Run("異常_範囲外", func(t *testing.T) {
+		if err := s.Set(4, 0); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := s.Bit(0, 100); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if err := s.Clear(-1, 0); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestSparseMatrixDotAndHammingDistance(t *testing.T) {
+	rng := rand.New(rand.NewPCG(85, 86))
+	for _, cols := range []int{1, 63, 64, 130} {
+		left, err := bitsx.NewRandMatrix(5, cols, -3, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		right, err := bitsx.NewRandMatrix(7, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		s, err := bitsx.NewSparseMatrixFromDense(left)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+
+		want, err := left.Dot(right)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err := s.Dot(right)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("cols = %d: Dotの不一致: got = %v, want = %v", cols, got, want)
+		}
+
+		same, err := bitsx.NewRandMatrix(5, cols, 0, rng)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		wantDistance, err := left.HammingDistance(same)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		gotDistance, err := s.HammingDistance(same)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if gotDistance != wantDistance {
+			t.Fatalf("cols = %d: HammingDistanceの不一致: got = %d, want = %d", cols, gotDistance, wantDistance)
+		}
+
+		if _, err := s.HammingDistance(right); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	}
+
+	t.Run("異常_列数の不一致", func(t *testing.T) {
+		s, err := bitsx.NewZerosSparseMatrix(2, 10)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		other, err := bitsx.NewZerosMatrix(2, 11)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := s.Dot(other); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestSparseMatrixGob(t *testing.T) {
+	rng := rand.New(rand.NewPCG(87, 88))
+	dense, err := bitsx.NewRandMatrix(4, 150, -3, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	want, err := bitsx.NewSparseMatrixFromDense(dense)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(want); err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	got := &bitsx.SparseMatrix{}
+	if err := gob.NewDecoder(&buf).Decode(got); err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Fatalf("gobの往復で内容が変化した")
+	}
+}
+
+func TestSparseMatrixGobDecodeInvalid(t *testing.T) {
+	type payload struct {
+		Rows    int
+		Cols    int
+		RowPtrs []int
+		ColIdxs []int
+	}
+
+	invalids := map[string]payload{
+		"rowPtrsが減少":     {Rows: 2, Cols: 10, RowPtrs: []int{0, 5, 2}, ColIdxs: []int{1, 2}},
+		"rowPtrsの長さ":     {Rows: 2, Cols: 10, RowPtrs: []int{0, 2}, ColIdxs: []int{1, 2}},
+		"列番号が範囲外":        {Rows: 1, Cols: 10, RowPtrs: []int{0, 2}, ColIdxs: []int{1, 10}},
+		"列番号が昇順でない":      {Rows: 1, Cols: 10, RowPtrs: []int{0, 2}, ColIdxs: []int{3, 3}},
+		"rowPtrsの末尾が不一致": {Rows: 1, Cols: 10, RowPtrs: []int{0, 1}, ColIdxs: []int{1, 2}},
+	}
+
+	for name, p := range invalids {
+		t.Run("異常_"+name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(p); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if err := (&bitsx.SparseMatrix{}).GobDecode(buf.Bytes()); err == nil {
+				t.Fatalf("エラーを期待したが、nilが返された")
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package bitsx
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// TernaryMatrixは、各要素が -1, 0, +1 のいずれかである行列。
+// 2枚のビットプレーンで表し、DotTernaryのカーネルにそのまま渡せる。
+//
+//	nonZero : 要素が0でなければ1
+//	sign    : 要素が+1なら1。nonZeroが0の位置では常に0に保つ
+type TernaryMatrix struct {
+	sign    *Matrix
+	nonZero *Matrix
+}
+
+type ternaryValue interface {
+	int8 | int
+}
+
+func NewZerosTernaryMatrix(rows, cols int) (*TernaryMatrix, error) {
+	sign, err := NewZerosMatrix(rows, cols)
+	if err != nil {
+		return nil, err
+	}
+	return &TernaryMatrix{sign: sign, nonZero: sign.Clone()}, nil
+}
+
+// NewTernaryMatrixは、行優先で並んだ -1, 0, +1 の値から行列を作る。
+func NewTernaryMatrix(rows, cols int, x []int8) (*TernaryMatrix, error) {
+	return newTernaryMatrix(rows, cols, x)
+}
+
+func NewTernaryMatrixFromInts(rows, cols int, x []int) (*TernaryMatrix, error) {
+	return newTernaryMatrix(rows, cols, x)
+}
+
+// NewTernaryMatrixFromFloat32は、重みを3値に量子化する。
+// |x| > threshold なら符号に応じて+1か-1、そうでなければ0とする。
+func NewTernaryMatrixFromFloat32(rows, cols int, x []float32, threshold float32) (*TernaryMatrix, error) {
+	if !(threshold >= 0) {
+		return nil, fmt.Errorf("threshold >= 0 であるべき: threshold = %v", threshold)
+	}
+
+	values := make([]int8, len(x))
+	for i, v := range x {
+		switch {
+		case v > threshold:
+			values[i] = 1
+		case v < -threshold:
+			values[i] = -1
+		}
+	}
+	return newTernaryMatrix(rows, cols, values)
+}
+
+// NewTernaryMatrixFromPlanesは、DotTernaryに渡していたsign・nonZeroから行列を作る。
+// 引数は複製する。nonZeroが0の位置のsignは無視する。
+func NewTernaryMatrixFromPlanes(sign, nonZero *Matrix) (*TernaryMatrix, error) {
+	if err := sign.ValidateSameShape(nonZero); err != nil {
+		return nil, err
+	}
+
+	if err := sign.validateDotAVX512Family(); err != nil {
+		return nil, err
+	}
+
+	t := &TernaryMatrix{sign: sign.Clone(), nonZero: nonZero.Clone()}
+	t.normalize()
+	return t, nil
+}
+
+func newTernaryMatrix[T ternaryValue](rows, cols int, x []T) (*TernaryMatrix, error) {
+	if n := rows * cols; n != len(x) {
+		return nil, fmt.Errorf("len(x) == rows * cols であるべき: len(x) = %d, rows = %d, cols = %d", len(x), rows, cols)
+	}
+
+	t, err := NewZerosTernaryMatrix(rows, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := t.sign.Stride()
+	for i, v := range x {
+		r, c := i/cols, i%cols
+		idx := r*stride + c/64
+		bit := uint64(1) << uint(c%64)
+		switch v {
+		case 1:
+			t.sign.data[idx] |= bit
+			t.nonZero.data[idx] |= bit
+		case -1:
+			t.nonZero.data[idx] |= bit
+		case 0:
+		default:
+			return nil, fmt.Errorf("x[%d]は -1, 0, 1 のいずれかであるべき: x[%d] = %d", i, i, v)
+		}
+	}
+	return t, nil
+}
+
+// 0の位置のsignを0にする。
+func (t *TernaryMatrix) normalize() {
+	for i, word := range t.nonZero.data {
+		t.sign.data[i] &= word
+	}
+	t.sign.invalidateRanks()
+	t.nonZero.invalidateRanks()
+}
+
+func (t *TernaryMatrix) Rows() int {
+	return t.sign.rows
+}
+
+func (t *TernaryMatrix) Cols() int {
+	return t.sign.cols
+}
+
+// Signは、+1の位置が1である行列の複製を返す。
+func (t *TernaryMatrix) Sign() *Matrix {
+	return t.sign.Clone()
+}
+
+// NonZeroは、0でない位置が1である行列の複製を返す。
+func (t *TernaryMatrix) NonZero() *Matrix {
+	return t.nonZero.Clone()
+}
+
+func (t *TernaryMatrix) Clone() *TernaryMatrix {
+	return &TernaryMatrix{sign: t.sign.Clone(), nonZero: t.nonZero.Clone()}
+}
+
+func (t *TernaryMatrix) Equal(other *TernaryMatrix) bool {
+	return t.sign.Equal(other.sign) && t.nonZero.Equal(other.nonZero)
+}
+
+// Bitは -1, 0, +1 のいずれかを返す。
+func (t *TernaryMatrix) Bit(r, c int) (int8, error) {
+	idx, shift, err := t.sign.IndexAndShift(r, c)
+	if err != nil {
+		return 0, err
+	}
+
+	if (t.nonZero.data[idx]>>shift)&1 == 0 {
+		return 0, nil
+	}
+
+	if (t.sign.data[idx]>>shift)&1 == 1 {
+		return 1, nil
+	}
+	return -1, nil
+}
+
+// Setは、(r, c)の要素をvにする。vは -1, 0, +1 のいずれかであるべき。
+func (t *TernaryMatrix) Set(r, c int, v int8) error {
+	idx, shift, err := t.sign.IndexAndShift(r, c)
+	if err != nil {
+		return err
+	}
+
+	bit := uint64(1) << shift
+	t.sign.invalidateRanks()
+	t.nonZero.invalidateRanks()
+	switch v {
+	case 1:
+		t.sign.data[idx] |= bit
+		t.nonZero.data[idx] |= bit
+	case -1:
+		t.sign.data[idx] &^= bit
+		t.nonZero.data[idx] |= bit
+	case 0:
+		t.sign.data[idx] &^= bit
+		t.nonZero.data[idx] &^= bit
+	default:
+		return fmt.Errorf("vは -1, 0, 1 のいずれかであるべき: v = %d", v)
+	}
+	return nil
+}
+
+// Dotは、value.DotTernary(sign, nonZero) と同じ。
+// valueの各ビットを1なら+1、0なら-1とみなし、結果の[r*t.Rows()+c]は、valueのr行目とtのc行目の内積。
+func (t *TernaryMatrix) Dot(value *Matrix) ([]int, error) {
+	return value.DotTernary(t.sign, t.nonZero)
+}
+
+// DotIntoはDotの結果を、呼び出し側が確保したdstへ書き込む。len(dst) == value.Rows() * t.Rows() であるべき。
+func (t *TernaryMatrix) DotInto(dst []int, value *Matrix) error {
+	return value.DotTernaryInto(dst, t.sign, t.nonZero)
+}
+
+type encodedTernaryMatrix struct {
+	Sign    *Matrix `json:"sign"`
+	NonZero *Matrix `json:"nonZero"`
+}
+
+func (t *TernaryMatrix) GobEncode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	payload := encodedTernaryMatrix{Sign: t.sign, NonZero: t.nonZero}
+	if err := gob.NewEncoder(buf).Encode(payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (t *TernaryMatrix) GobDecode(b []byte) error {
+	var payload encodedTernaryMatrix
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&payload); err != nil {
+		return err
+	}
+	return t.setDecoded(payload)
+}
+
+// MarshalJSONは、sign・nonZeroをそれぞれMatrixのJSONとして書き出す。
+func (t *TernaryMatrix) MarshalJSON() ([]byte, error) {
+	return json.Marshal(encodedTernaryMatrix{Sign: t.sign, NonZero: t.nonZero})
+}
+
+func (t *TernaryMatrix) UnmarshalJSON(b []byte) error {
+	var payload encodedTernaryMatrix
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return err
+	}
+	return t.setDecoded(payload)
+}
+
+func (t *TernaryMatrix) setDecoded(payload encodedTernaryMatrix) error {
+	if payload.Sign == nil || payload.NonZero == nil {
+		return fmt.Errorf("デコードされたTernaryMatrixが不正: signとnonZeroの両方が必要")
+	}
+
+	if err := payload.Sign.ValidateSameShape(payload.NonZero); err != nil {
+		return fmt.Errorf("デコードされたTernaryMatrixが不正: %w", err)
+	}
+
+	*t = TernaryMatrix{sign: payload.Sign, nonZero: payload.NonZero}
+	t.normalize()
+	return nil
+}
@@ -0,0 +1,213 @@
+package bitsx_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func newRandTernaryValues(rng *rand.Rand, n int) []int8 {
+	x := make([]int8, n)
+	for i := range x {
+		x[i] = int8(rng.IntN(3) - 1)
+	}
+	return x
+}
+
+func TestNewTernaryMatrix(t *testing.T) {
+	rng := rand.New(rand.NewPCG(57, 58))
+	rows, cols := 3, 70
+	x := newRandTernaryValues(rng, rows*cols)
+
+	tm, err := bitsx.NewTernaryMatrix(rows, cols, x)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	for i, want := range x {
+		got, err := tm.Bit(i/cols, i%cols)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got != want {
+			t.Fatalf("(%d, %d)の値の不一致: got = %d, want = %d", i/cols, i%cols, got, want)
+		}
+	}
+
+	t.Run("正常_intからも同じ行列になる", func(t *testing.T) {
+		ints := make([]int, len(x))
+		for i, v := range x {
+			ints[i] = int(v)
+		}
+		got, err := bitsx.NewTernaryMatrixFromInts(rows, cols, ints)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !got.Equal(tm) {
+			t.Errorf("NewTernaryMatrixと一致しない")
+		}
+	})
+
+	t.Run("正常_float32の量子化", func(t *testing.T) {
+		got, err := bitsx.NewTernaryMatrixFromFloat32(1, 5, []float32{0.7, -0.2, 0.5, -0.51, 0}, 0.5)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		want := []int8{1, 0, 0, -1, 0}
+		for c, w := range want {
+			if v, _ := got.Bit(0, c); v != w {
+				t.Errorf("%d列目の値の不一致: got = %d, want = %d", c, v, w)
+			}
+		}
+	})
+
+	t.Run("正常_planesから作るとsignが正規化される", func(t *testing.T) {
+		sign, err := bitsx.ParseMatrix("[1111]")
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		nonZero, err := bitsx.ParseMatrix("[1010]")
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err := bitsx.NewTernaryMatrixFromPlanes(sign, nonZero)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if s := got.Sign().String(); s != "[1010]" {
+			t.Errorf("signの不一致: got = %s, want = [1010]", s)
+		}
+		want, err := bitsx.NewTernaryMatrix(1, 4, []int8{1, 0, 1, 0})
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("値の不一致")
+		}
+	})
+
+	t.Run("異常_引数が不正", func(t *testing.T) {
+		if _, err := bitsx.NewTernaryMatrix(1, 3, []int8{1, 2, 0}); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := bitsx.NewTernaryMatrixFromInts(2, 3, []int{1, 0, 0}); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := bitsx.NewTernaryMatrixFromFloat32(1, 1, []float32{1}, -1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestTernaryMatrixSet(t *testing.T) {
+	tm, err := bitsx.NewZerosTernaryMatrix(2, 3)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	for _, v := range []int8{1, -1, 0, -1, 1} {
+		if err := tm.Set(1, 2, v); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got, _ := tm.Bit(1, 2); got != v {
+			t.Fatalf("値の不一致: got = %d, want = %d", got, v)
+		}
+	}
+
+	if err := tm.Set(0, 0, 2); err == nil {
+		t.Fatalf("エラーを期待したが、nilが返された")
+	}
+	if err := tm.Set(2, 0, 1); err == nil {
+		t.Fatalf("エラーを期待したが、nilが返された")
+	}
+}
+
+func TestTernaryMatrixDot(t *testing.T) {
+	rng := rand.New(rand.NewPCG(59, 60))
+	cols := 130
+	x := newRandTernaryValues(rng, 4*cols)
+	tm, err := bitsx.NewTernaryMatrix(4, cols, x)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	value, err := bitsx.NewRandMatrix(3, cols, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	got, err := tm.Dot(value)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	want := make([]int, 3*4)
+	for r := range 3 {
+		for c := range 4 {
+			for k := range cols {
+				v := 2*int(mustBit(t, value, r, k)) - 1
+				want[r*4+c] += v * int(x[c*cols+k])
+			}
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("値の不一致: got = %v, want = %v", got, want)
+	}
+
+	dst := make([]int, len(want))
+	if err := tm.DotInto(dst, value); err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if !slices.Equal(dst, want) {
+		t.Fatalf("DotIntoの値の不一致: got = %v, want = %v", dst, want)
+	}
+}
+
+func TestTernaryMatrixEncoding(t *testing.T) {
+	rng := rand.New(rand.NewPCG(61, 62))
+	tm, err := bitsx.NewTernaryMatrix(3, 70, newRandTernaryValues(rng, 3*70))
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	t.Run("正常_gob", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(tm); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got := &bitsx.TernaryMatrix{}
+		if err := gob.NewDecoder(&buf).Decode(got); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !got.Equal(tm) {
+			t.Errorf("復元した行列が一致しない")
+		}
+	})
+
+	t.Run("正常_JSON", func(t *testing.T) {
+		b, err := json.Marshal(tm)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got := &bitsx.TernaryMatrix{}
+		if err := json.Unmarshal(b, got); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if !got.Equal(tm) {
+			t.Errorf("復元した行列が一致しない")
+		}
+	})
+
+	t.Run("異常_JSONの形状の不一致", func(t *testing.T) {
+		b := []byte(`{"sign":{"rows":1,"cols":2,"bits":["10"]},"nonZero":{"rows":1,"cols":3,"bits":["100"]}}`)
+		if err := json.Unmarshal(b, &bitsx.TernaryMatrix{}); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if err := json.Unmarshal([]byte(`{"sign":{"rows":1,"cols":2,"bits":["10"]}}`), &bitsx.TernaryMatrix{}); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
@@ -0,0 +1,303 @@
+package bitsx
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+// Vectorは、任意の長さのビット列。Matrixと同じく、端数ビット(Len % 64 の範囲外)は常に0に保つ。
+//
+// Rank・Selectは、BuildRankIndexで作った標本化された索引を使う。索引が無ければエラーを返す。
+// ビットを変更するメソッドは索引を破棄する。Rank・Selectは読み取りだけなので、
+// 索引を作った後は複数のgoroutineから同時に呼んでよい。
+type Vector struct {
+	n     int
+	data  []uint64
+	index *rankIndex
+}
+
+// NewVectorは、長さnで全て0のVectorを作る。n = 0 でもよい。
+func NewVector(n int) (*Vector, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("n >= 0 であるべき: n = %d", n)
+	}
+	return &Vector{n: n, data: make([]uint64, (n+63)/64)}, nil
+}
+
+// NewVectorFromIndicesは、idxsの位置だけが1である長さnのVectorを作る。
+func NewVectorFromIndices(n int, idxs []int) (*Vector, error) {
+	v, err := NewVector(n)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idx := range idxs {
+		if err := v.Set(idx); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// RowVectorは、r行目を複製したVectorを返す。
+func (m *Matrix) RowVector(r int) (*Vector, error) {
+	if r < 0 || r >= m.rows {
+		return nil, fmt.Errorf("0 <= row < %d であるべき: row = %d", m.rows, r)
+	}
+
+	stride := m.Stride()
+	return &Vector{n: m.cols, data: slices.Clone(m.data[r*stride : (r+1)*stride])}, nil
+}
+
+// ToMatrixは、(1 x Len) の行列を返す。DotやHammingIndexのqueryに使える。Len > 0 であるべき。
+func (v *Vector) ToMatrix() (*Matrix, error) {
+	m, err := NewZerosMatrix(1, v.n)
+	if err != nil {
+		return nil, err
+	}
+	copy(m.data, v.data)
+	return m, nil
+}
+
+func (v *Vector) Len() int {
+	return v.n
+}
+
+func (v *Vector) Clone() *Vector {
+	return &Vector{n: v.n, data: slices.Clone(v.data)}
+}
+
+func (v *Vector) Equal(other *Vector) bool {
+	return v.n == other.n && slices.Equal(v.data, other.data)
+}
+
+// Resizeは長さをnに変える。伸ばした部分は0になり、縮めた部分のビットは捨てる。
+func (v *Vector) Resize(n int) error {
+	if n < 0 {
+		return fmt.Errorf("n >= 0 であるべき: n = %d", n)
+	}
+
+	words := (n + 63) / 64
+	if words <= len(v.data) {
+		v.data = v.data[:words]
+	} else {
+		v.data = append(v.data, make([]uint64, words-len(v.data))...)
+	}
+	v.n = n
+	v.applyTailMask()
+	v.index = nil
+	return nil
+}
+
+func (v *Vector) applyTailMask() {
+	if r := v.n % 64; r != 0 {
+		v.data[len(v.data)-1] &= (uint64(1) << uint(r)) - 1
+	}
+}
+
+func (v *Vector) validateIndex(idx int) error {
+	if idx < 0 || idx >= v.n {
+		return fmt.Errorf("0 <= index < %d であるべき: index = %d", v.n, idx)
+	}
+	return nil
+}
+
+func (v *Vector) Bit(idx int) (uint64, error) {
+	if err := v.validateIndex(idx); err != nil {
+		return 0, err
+	}
+	return (v.data[idx/64] >> uint(idx%64)) & 1, nil
+}
+
+func (v *Vector) Set(idx int) error {
+	if err := v.validateIndex(idx); err != nil {
+		return err
+	}
+	v.data[idx/64] |= 1 << uint(idx%64)
+	v.index = nil
+	return nil
+}
+
+func (v *Vector) Clear(idx int) error {
+	if err := v.validateIndex(idx); err != nil {
+		return err
+	}
+	v.data[idx/64] &^= 1 << uint(idx%64)
+	v.index = nil
+	return nil
+}
+
+func (v *Vector) Toggle(idx int) error {
+	if err := v.validateIndex(idx); err != nil {
+		return err
+	}
+	v.data[idx/64] ^= 1 << uint(idx%64)
+	v.index = nil
+	return nil
+}
+
+// 〇〇InPlace(other) は、vと〇〇した結果をvへ書き込む。〇〇(other)は、結果を新たなVectorで返す。
+
+func (v *Vector) validateSameLen(other *Vector) error {
+	if v.n != other.n {
+		return fmt.Errorf("長さの不一致: %d vs %d", v.n, other.n)
+	}
+	return nil
+}
+
+func (v *Vector) AndInPlace(other *Vector) error {
+	if err := v.validateSameLen(other); err != nil {
+		return err
+	}
+	for i, word := range other.data {
+		v.data[i] &= word
+	}
+	v.index = nil
+	return nil
+}
+
+func (v *Vector) OrInPlace(other *Vector) error {
+	if err := v.validateSameLen(other); err != nil {
+		return err
+	}
+	for i, word := range other.data {
+		v.data[i] |= word
+	}
+	v.index = nil
+	return nil
+}
+
+func (v *Vector) XorInPlace(other *Vector) error {
+	if err := v.validateSameLen(other); err != nil {
+		return err
+	}
+	for i, word := range other.data {
+		v.data[i] ^= word
+	}
+	v.index = nil
+	return nil
+}
+
+func (v *Vector) AndNotInPlace(other *Vector) error {
+	if err := v.validateSameLen(other); err != nil {
+		return err
+	}
+	for i, word := range other.data {
+		v.data[i] &^= word
+	}
+	v.index = nil
+	return nil
+}
+
+func (v *Vector) NotInPlace() {
+	for i, word := range v.data {
+		v.data[i] = ^word
+	}
+	v.applyTailMask()
+	v.index = nil
+}
+
+func (v *Vector) And(other *Vector) (*Vector, error) {
+	c := v.Clone()
+	if err := c.AndInPlace(other); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (v *Vector) Or(other *Vector) (*Vector, error) {
+	c := v.Clone()
+	if err := c.OrInPlace(other); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (v *Vector) Xor(other *Vector) (*Vector, error) {
+	c := v.Clone()
+	if err := c.XorInPlace(other); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (v *Vector) AndNot(other *Vector) (*Vector, error) {
+	c := v.Clone()
+	if err := c.AndNotInPlace(other); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (v *Vector) Not() *Vector {
+	c := v.Clone()
+	c.NotInPlace()
+	return c
+}
+
+func (v *Vector) OnesCount() int {
+	if v.index != nil {
+		return v.index.ones()
+	}
+
+	count := 0
+	for _, word := range v.data {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// Indicesは、スカラーのIndicesと同じく、1の位置を昇順に返す。
+func (v *Vector) Indices() []int {
+	idxs := make([]int, 0, v.OnesCount())
+	for i, word := range v.data {
+		for ; word != 0; word = ClearLowest(word) {
+			idxs = append(idxs, i*64+bits.TrailingZeros64(word))
+		}
+	}
+	return idxs
+}
+
+// BuildRankIndexは、Rank・Selectの索引を作る。索引が有効であれば何もしない。
+func (v *Vector) BuildRankIndex() {
+	if v.index == nil {
+		v.index = newRankIndex(v.data)
+	}
+}
+
+// HasRankIndexは、有効な索引があるかを返す。
+func (v *Vector) HasRankIndex() bool {
+	return v.index != nil
+}
+
+func (v *Vector) validateRankIndex() error {
+	if v.index == nil {
+		return fmt.Errorf("rank・selectの索引が無い: 先にBuildRankIndexを呼ぶべき")
+	}
+	return nil
+}
+
+// Rankは、i未満の位置にある1の数を返す。0 <= i <= Len であるべき。
+func (v *Vector) Rank(i int) (int, error) {
+	if err := v.validateRankIndex(); err != nil {
+		return 0, err
+	}
+
+	if i < 0 || i > v.n {
+		return 0, fmt.Errorf("0 <= i <= %d であるべき: i = %d", v.n, i)
+	}
+	return v.index.rank(v.data, i), nil
+}
+
+// Selectは、k番目(0始まり)の1の位置を返す。0 <= k < OnesCount であるべき。
+func (v *Vector) Select(k int) (int, error) {
+	if err := v.validateRankIndex(); err != nil {
+		return 0, err
+	}
+
+	if ones := v.index.ones(); k < 0 || k >= ones {
+		return 0, fmt.Errorf("0 <= k < %d であるべき: k = %d", ones, k)
+	}
+	return v.index.select1(v.data, k), nil
+}
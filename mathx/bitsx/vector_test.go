@@ -0,0 +1,327 @@
+package bitsx_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/sw965/omw/mathx/bitsx"
+)
+
+func newRandVector(t *testing.T, rng *rand.Rand, n int, p float64) (*bitsx.Vector, []bool) {
+	t.Helper()
+	v, err := bitsx.NewVector(n)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	want := make([]bool, n)
+	for i := range n {
+		if rng.Float64() < p {
+			want[i] = true
+			if err := v.Set(i); err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+		}
+	}
+	return v, want
+}
+
+func TestVectorBitOps(t *testing.T) {
+	v, err := bitsx.NewVector(70)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	steps := []struct {
+		op   func(int) error
+		idx  int
+		want uint64
+	}{
+		{v.Set, 69, 1},
+		{v.Toggle, 69, 0},
+		{v.Toggle, 0, 1},
+		{v.Clear, 0, 0},
+		{v.Set, 64, 1},
+	}
+	for i, s := range steps {
+		if err := s.op(s.idx); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		got, err := v.Bit(s.idx)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if got != s.want {
+			t.Fatalf("%d: 値の不一致: got = %d, want = %d", i, got, s.want)
+		}
+	}
+
+	t.Run("異常_範囲外", func(t *testing.T) {
+		if err := v.Set(70); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := v.Bit(-1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := bitsx.NewVector(-1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestVectorLogic(t *testing.T) {
+	rng := rand.New(rand.NewPCG(95, 96))
+	a, wantA := newRandVector(t, rng, 130, 0.5)
+	b, wantB := newRandVector(t, rng, 130, 0.5)
+
+	ops := []struct {
+		name string
+		got  func() (*bitsx.Vector, error)
+		want func(x, y bool) bool
+	}{
+		{"And", func() (*bitsx.Vector, error) { return a.And(b) }, func(x, y bool) bool { return x && y }},
+		{"Or", func() (*bitsx.Vector, error) { return a.Or(b) }, func(x, y bool) bool { return x || y }},
+		{"Xor", func() (*bitsx.Vector, error) { return a.Xor(b) }, func(x, y bool) bool { return x != y }},
+		{"AndNot", func() (*bitsx.Vector, error) { return a.AndNot(b) }, func(x, y bool) bool { return x && !y }},
+		{"Not", func() (*bitsx.Vector, error) { return a.Not(), nil }, func(x, y bool) bool { return !x }},
+	}
+
+	for _, op := range ops {
+		t.Run("正常_"+op.name, func(t *testing.T) {
+			got, err := op.got()
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			ones := 0
+			for i := range 130 {
+				bit, _ := got.Bit(i)
+				want := op.want(wantA[i], wantB[i])
+				if (bit == 1) != want {
+					t.Fatalf("%d番目の値の不一致", i)
+				}
+				if want {
+					ones++
+				}
+			}
+			// 端数ビットが0であれば、OnesCountは論理的なビットだけを数える
+			if got.OnesCount() != ones {
+				t.Fatalf("端数ビットが0に保たれていない")
+			}
+		})
+	}
+
+	t.Run("異常_長さの不一致", func(t *testing.T) {
+		c, err := bitsx.NewVector(129)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := a.And(c); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestVectorRankAndSelect(t *testing.T) {
+	rng := rand.New(rand.NewPCG(97, 98))
+
+	// 索引のブロック(512ビット)と標本(1の512個ごと)を、疎・密の両方で跨ぐ
+	for _, tc := range []struct {
+		n int
+		p float64
+	}{{1, 1}, {64, 0.5}, {600, 0.9}, {5000, 0.02}, {20000, 0.5}, {20000, 0.999}} {
+		v, want := newRandVector(t, rng, tc.n, tc.p)
+		v.BuildRankIndex()
+
+		var positions []int
+		for i := 0; i <= tc.n; i++ {
+			got, err := v.Rank(i)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if got != len(positions) {
+				t.Fatalf("n = %d: Rank(%d)の不一致: got = %d, want = %d", tc.n, i, got, len(positions))
+			}
+			if i < tc.n && want[i] {
+				positions = append(positions, i)
+			}
+		}
+
+		for k, pos := range positions {
+			got, err := v.Select(k)
+			if err != nil {
+				t.Fatalf("予期せぬエラー: %v", err)
+			}
+			if got != pos {
+				t.Fatalf("n = %d: Select(%d)の不一致: got = %d, want = %d", tc.n, k, got, pos)
+			}
+		}
+
+		if !slices.Equal(v.Indices(), positions) {
+			t.Fatalf("n = %d: Indicesの不一致", tc.n)
+		}
+		if _, err := v.Select(len(positions)); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := v.Rank(tc.n + 1); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	}
+
+	t.Run("正常_変更で索引が破棄される", func(t *testing.T) {
+		v, err := bitsx.NewVectorFromIndices(1000, []int{10, 900})
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		v.BuildRankIndex()
+		if got, _ := v.Rank(1000); got != 2 {
+			t.Fatalf("Rankの不一致: got = %d, want = 2", got)
+		}
+		if err := v.Set(500); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if v.HasRankIndex() {
+			t.Fatalf("Setの後も索引が残っている")
+		}
+		if _, err := v.Rank(1000); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+
+		v.BuildRankIndex()
+		if got, _ := v.Rank(1000); got != 3 {
+			t.Errorf("Setの後のRankの不一致: got = %d, want = 3", got)
+		}
+		if got, _ := v.Select(1); got != 500 {
+			t.Errorf("Setの後のSelectの不一致: got = %d, want = 500", got)
+		}
+		v.NotInPlace()
+		v.BuildRankIndex()
+		if got, _ := v.Rank(1000); got != 997 {
+			t.Errorf("NotInPlaceの後のRankの不一致: got = %d, want = 997", got)
+		}
+	})
+
+	t.Run("異常_索引が無い", func(t *testing.T) {
+		v, err := bitsx.NewVectorFromIndices(100, []int{3})
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := v.Rank(10); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if _, err := v.Select(0); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		if v.HasRankIndex() {
+			t.Fatalf("Rank・Selectが索引を作った")
+		}
+	})
+
+	t.Run("正常_複数のgoroutineから同時に呼べる", func(t *testing.T) {
+		v, want := newRandVector(t, rng, 5000, 0.5)
+		v.BuildRankIndex()
+		ones := 0
+		for _, b := range want {
+			if b {
+				ones++
+			}
+		}
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Go(func() {
+				for k := 0; k < ones; k += 7 {
+					pos, err := v.Select(k)
+					if err != nil {
+						t.Errorf("予期せぬエラー: %v", err)
+						return
+					}
+					if got, _ := v.Rank(pos); got != k {
+						t.Errorf("Rank(Select(%d))の不一致: got = %d", k, got)
+						return
+					}
+				}
+			})
+		}
+		wg.Wait()
+	})
+}
+
+func TestVectorMatrixInterop(t *testing.T) {
+	rng := rand.New(rand.NewPCG(99, 100))
+	m, err := bitsx.NewRandMatrix(4, 130, 0, rng)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	v, err := m.RowVector(2)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	query, err := v.ToMatrix()
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	want, err := m.SubMatrix(2, 3, 0, 130)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if !query.Equal(want) {
+		t.Fatalf("2行目と一致しない")
+	}
+
+	// queryとして使える
+	got, err := query.Dot(m)
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if got[2] != 130 {
+		t.Errorf("自身との一致数の不一致: got = %d, want = 130", got[2])
+	}
+
+	t.Run("正常_RowVectorは複製", func(t *testing.T) {
+		if err := v.Toggle(0); err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if mustBit(t, m, 2, 0) != mustBit(t, query, 0, 0) {
+			t.Errorf("元の行列が変更された")
+		}
+	})
+
+	t.Run("異常_範囲外の行と空のVector", func(t *testing.T) {
+		if _, err := m.RowVector(4); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+		empty, err := bitsx.NewVector(0)
+		if err != nil {
+			t.Fatalf("予期せぬエラー: %v", err)
+		}
+		if _, err := empty.ToMatrix(); err == nil {
+			t.Fatalf("エラーを期待したが、nilが返された")
+		}
+	})
+}
+
+func TestVectorResize(t *testing.T) {
+	v, err := bitsx.NewVectorFromIndices(100, []int{3, 70, 99})
+	if err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+
+	if err := v.Resize(71); err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	if err := v.Resize(200); err != nil {
+		t.Fatalf("予期せぬエラー: %v", err)
+	}
+	// 縮めた時に捨てた99番目は、伸ばしても0のまま
+	if got := v.Indices(); !slices.Equal(got, []int{3, 70}) {
+		t.Errorf("値の不一致: got = %v, want = [3 70]", got)
+	}
+	if v.Len() != 200 {
+		t.Errorf("長さの不一致: got = %d, want = 200", v.Len())
+	}
+	if err := v.Resize(-1); err == nil {
+		t.Fatalf("エラーを期待したが、nilが返された")
+	}
+}